@@ -1,32 +1,87 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"tages-task-go/internal/config"
 	"tages-task-go/internal/service/db/postgresql"
 	"tages-task-go/internal/transport/http"
 	"tages-task-go/internal/usecase"
+	"tages-task-go/pkg/auth"
 	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/metrics"
+	"tages-task-go/pkg/ratelimit"
+	"tages-task-go/pkg/tracing"
 )
 
 var DbPool *pgxpool.Pool
 
+// readiness переводится в состояние "не готов" в начале завершения работы
+var readiness interface{ BeginShutdown(ctx context.Context) }
+
+// shutdownTracing отправляет оставшиеся спаны при завершении работы
+var shutdownTracing tracing.Shutdown = func(context.Context) error { return nil }
+
+// stopBackground останавливает фоновые задачи, например очистку истекших ключей идемпотентности
+var stopBackground context.CancelFunc = func() {}
+
 // Initialize инициализирует необходимые компоненты приложения
 func Initialize() (*mux.Router, error) {
 	logger := logging.GetLogger()
 	logger.Infof("Initializing server")
+	cfg := config.GetConfig()
+	if err := logging.Configure(cfg.Logging); err != nil {
+		return nil, fmt.Errorf("failed to configure logging: %w", err)
+	}
+
+	// Ключи проверки токенов доступа загружаем до подключения к базе, чтобы сразу сообщить об ошибке конфигурации
+	verifier, err := auth.NewJWTVerifier(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure authentication: %w", err)
+	}
+	shutdownTracing, err = tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure tracing: %w", err)
+	}
+	limiter, err := ratelimit.NewLimiter(cfg.RateLimit, ratelimit.NewMemoryStore())
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure rate limiting: %w", err)
+	}
+
 	// Подключение к базе данных
-	DbPool = postgresql.InitDB(logger)
+	DbPool, err = postgresql.InitDB(logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	if err := metrics.Register(metrics.NewPoolCollector(DbPool)); err != nil {
+		return nil, fmt.Errorf("failed to register database pool metrics: %w", err)
+	}
 
 	// Инициализация репозиториев и юзкейсов
-	productRepo := postgresql.NewProductRepository(DbPool, logger)
-	orderRepo := postgresql.NewOrderRepository(DbPool, logger)
-	productUC := usecase.NewProductUseCase(productRepo, logger)
-	orderUC := usecase.NewOrderUseCase(orderRepo, logger)
-	storeUC := http.NewStoreUseCase(orderUC, productUC)
+	productRepo := postgresql.NewProductRepository(DbPool)
+	orderRepo := postgresql.NewOrderRepository(DbPool)
+	customerRepo := postgresql.NewCustomerRepository(DbPool)
+	idempotencyRepo := postgresql.NewIdempotencyRepository(DbPool)
+	apiKeyRepo := postgresql.NewAPIKeyRepository(DbPool)
+	healthRepo := postgresql.NewHealthRepository(DbPool)
+	productUC := usecase.NewProductUseCase(productRepo)
+	orderUC := usecase.NewOrderUseCase(orderRepo)
+	customerUC := usecase.NewCustomerUseCase(customerRepo, orderRepo)
+	idempotencyUC := usecase.NewIdempotencyUseCase(idempotencyRepo, cfg.Idempotency.KeyTTL)
+	apiKeyUC := usecase.NewAPIKeyUseCase(apiKeyRepo)
+	healthUC := usecase.NewHealthUseCase(healthRepo)
+	readiness = healthUC
+	storeUC := http.NewStoreUseCase(orderUC, productUC, customerUC, idempotencyUC, apiKeyUC, healthUC)
+
+	// Истекшие ключи идемпотентности удаляются в фоне до начала завершения работы
+	background, cancel := context.WithCancel(context.Background())
+	stopBackground = cancel
+	go idempotencyUC.RunPurger(background, cfg.Idempotency.PurgeInterval)
 
 	// Инициализация хендлеров и маршрутов
-	handler := http.NewHandler(storeUC)
+	handler := http.NewHandler(storeUC, verifier, limiter)
 	router := handler.InitRoutes()
 
 	return router, nil
@@ -2,11 +2,13 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"sync"
 	"tages-task-go/internal/config"
+	"time"
 )
 
 var httpServer *http.Server
@@ -29,8 +31,18 @@ func Run(router http.Handler, wg *sync.WaitGroup) {
 	}
 }
 
-// Shutdown корректно завершает работу сервера
+// Shutdown корректно завершает работу сервера. Сначала проверка готовности начинает отвечать 503,
+// и в течение drain_delay сервер еще принимает запросы, пока балансировщик не исключит его из ротации.
+// Затем сервер перестает принимать соединения и дожидается обработки начатых запросов.
+// Оставшиеся спаны отправляются, даже если запросы не успели завершиться
 func Shutdown(ctx context.Context) error {
 	log.Println("Завершение работы сервера...")
-	return httpServer.Shutdown(ctx)
+	readiness.BeginShutdown(ctx)
+	stopBackground()
+	select {
+	case <-time.After(config.GetConfig().Listen.DrainDelay):
+	case <-ctx.Done():
+	}
+
+	return errors.Join(httpServer.Shutdown(ctx), shutdownTracing(ctx))
 }
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// envPrefix - префикс переменных окружения: storage.host задается переменной TAGES_STORAGE_HOST
+	envPrefix = "TAGES_"
+	// configFilesEnv - переменная со списком файлов конфигурации через запятую
+	configFilesEnv = "TAGES_CONFIG"
+	// defaultConfigFile читается, если файлы не указаны ни флагом, ни переменной окружения
+	defaultConfigFile = "config.yml"
+	// redacted заменяет значения секретов при выводе конфигурации
+	redacted = "******"
+)
+
+// Load собирает конфигурацию из слоев, каждый следующий переопределяет предыдущий:
+//  1. значения по умолчанию из тегов env-default;
+//  2. файлы из флагов --config (можно указать несколько), из TAGES_CONFIG или config.yml;
+//  3. переменные окружения TAGES_<ПУТЬ>, например TAGES_STORAGE_HOST. Секреты можно передать файлом:
+//     TAGES_<ПУТЬ>_FILE, например TAGES_AUTH_HMAC_SECRET_FILE=/run/secrets/jwt;
+//  4. флаги командной строки вида --storage.host=db.local.
+//
+// Итоговая конфигурация проверяется через Validate. Второе значение сообщает, что передан флаг --print-config
+func Load(args []string) (*Config, bool, error) {
+	cfg := &Config{}
+	fields := configFields(reflect.ValueOf(cfg).Elem(), "")
+
+	flags := flag.NewFlagSet("tages-task-go", flag.ContinueOnError)
+	var files fileList
+	flags.Var(&files, "config", "configuration file, can be repeated; later files override earlier ones (env "+configFilesEnv+")")
+	printConfig := flags.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	overrides := make(map[string]string)
+	for _, f := range fields {
+		f := f
+		flags.Func(f.path, fmt.Sprintf("overrides %s (env %s)", f.path, f.envName()), func(value string) error {
+			overrides[f.path] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	for _, f := range fields {
+		if value, ok := f.tag.Lookup("env-default"); ok {
+			if err := f.set(value); err != nil {
+				return nil, false, fmt.Errorf("invalid default for %s: %w", f.path, err)
+			}
+		}
+	}
+
+	if err := readFiles(cfg, files); err != nil {
+		return nil, false, err
+	}
+
+	if err := applyEnv(fields); err != nil {
+		return nil, false, err
+	}
+	for _, f := range fields {
+		if value, ok := overrides[f.path]; ok {
+			if err := f.set(value); err != nil {
+				return nil, false, fmt.Errorf("invalid value of --%s: %w", f.path, err)
+			}
+		}
+	}
+
+	if cfg.Storage.PasswordFile != "" {
+		password, err := readSecretFile(cfg.Storage.PasswordFile)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read storage.password_file: %w", err)
+		}
+		cfg.Storage.Password = password
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, false, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, *printConfig, nil
+}
+
+// applyEnv применяет переменные окружения. Значение поля с тегом secret:"true" можно также прочитать
+// из файла, путь к которому задан переменной с суффиксом _FILE
+func applyEnv(fields []configField) error {
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[f.envName()] = true
+	}
+
+	for _, f := range fields {
+		value, ok := os.LookupEnv(f.envName())
+		if ok {
+			if err := f.set(value); err != nil {
+				return fmt.Errorf("invalid value of %s: %w", f.envName(), err)
+			}
+		}
+
+		// Переменная TAGES_STORAGE_PASSWORD_FILE принадлежит полю storage.password_file, а не паролю
+		fileEnv := f.envName() + "_FILE"
+		if f.tag.Get("secret") != "true" || names[fileEnv] {
+			continue
+		}
+		path, found := os.LookupEnv(fileEnv)
+		if !found {
+			continue
+		}
+		if ok {
+			return fmt.Errorf("only one of %s and %s can be set", f.envName(), fileEnv)
+		}
+		secret, err := readSecretFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", fileEnv, err)
+		}
+		if err := f.set(secret); err != nil {
+			return fmt.Errorf("invalid value in %s: %w", fileEnv, err)
+		}
+	}
+	return nil
+}
+
+// readSecretFile читает секрет из файла. Завершающий перевод строки, который добавляют редакторы
+// и echo, отбрасывается
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readFiles читает файлы конфигурации по очереди. Отсутствие config.yml по умолчанию не ошибка,
+// а явно указанный файл должен существовать
+func readFiles(cfg *Config, files []string) error {
+	if len(files) == 0 {
+		if value := os.Getenv(configFilesEnv); value != "" {
+			files = strings.Split(value, ",")
+		}
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(defaultConfigFile); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		files = []string{defaultConfigFile}
+	}
+
+	for _, path := range files {
+		if err := readFile(cfg, strings.TrimSpace(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	// Неизвестные ключи - почти всегда опечатка, молча игнорировать их нельзя
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print выводит конфигурацию в YAML, заменяя значения полей с тегом secret:"true"
+func Print(w io.Writer, cfg *Config) error {
+	printable := *cfg
+	for _, f := range configFields(reflect.ValueOf(&printable).Elem(), "") {
+		if f.tag.Get("secret") == "true" && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(printable); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// fileList - значение повторяемого флага --config
+type fileList []string
+
+func (l *fileList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *fileList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// configField - поле конфигурации, которое задается одним значением: строкой, числом, флагом или длительностью.
+// path - путь из имен YAML через точку, например storage.host
+type configField struct {
+	path  string
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+// configFields обходит вложенные структуры конфигурации и возвращает все поля со скалярными значениями.
+// Словари, например rate_limit.groups, задаются только в файле
+func configFields(v reflect.Value, prefix string) []configField {
+	var fields []configField
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			fields = append(fields, configFields(v.Field(i), path+".")...)
+		case field.Type.Kind() == reflect.Map || field.Type.Kind() == reflect.Slice:
+			continue
+		default:
+			fields = append(fields, configField{path: path, value: v.Field(i), tag: field.Tag})
+		}
+	}
+	return fields
+}
+
+func (f configField) envName() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(f.path, ".", "_"))
+}
+
+// set разбирает строковое значение в соответствии с типом поля
+func (f configField) set(raw string) error {
+	if f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, f.value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, f.value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.value.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
+	}
+	return nil
+}
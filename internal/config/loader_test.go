@@ -0,0 +1,269 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSecret - секрет HS256 допустимой длины, без него конфигурация не проходит проверку
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// setRequiredSecrets задает через окружение секреты, без которых конфигурация не проходит проверку
+func setRequiredSecrets(t *testing.T) {
+	t.Helper()
+	t.Setenv("TAGES_AUTH_HMAC_SECRET", testSecret)
+	t.Setenv("TAGES_STORAGE_PASSWORD", "test-password")
+}
+
+// writeFile создает файл во временном каталоге теста и возвращает путь к нему
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		env       map[string]string
+		args      []string
+		wantPort  string
+		wantDrain time.Duration
+	}{
+		{
+			name:      "defaults",
+			wantPort:  "8080",
+			wantDrain: 2 * time.Second,
+		},
+		{
+			name:      "file overrides defaults",
+			files:     []string{"listen:\n  port: 9000\n"},
+			wantPort:  "9000",
+			wantDrain: 2 * time.Second,
+		},
+		{
+			name:      "later file overrides earlier",
+			files:     []string{"listen:\n  port: 9000\n  drain_delay: 1s\n", "listen:\n  port: 9001\n"},
+			wantPort:  "9001",
+			wantDrain: time.Second,
+		},
+		{
+			name:      "env overrides file",
+			files:     []string{"listen:\n  port: 9000\n"},
+			env:       map[string]string{"TAGES_LISTEN_PORT": "9100", "TAGES_LISTEN_DRAIN_DELAY": "3s"},
+			wantPort:  "9100",
+			wantDrain: 3 * time.Second,
+		},
+		{
+			name:      "flag overrides env",
+			files:     []string{"listen:\n  port: 9000\n"},
+			env:       map[string]string{"TAGES_LISTEN_PORT": "9100"},
+			args:      []string{"--listen.port=9200", "--listen.drain_delay", "500ms"},
+			wantPort:  "9200",
+			wantDrain: 500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredSecrets(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			var args []string
+			for i, content := range tt.files {
+				args = append(args, "--config", writeFile(t, "config"+strconv.Itoa(i)+".yml", content))
+			}
+			if len(tt.files) == 0 {
+				// Без файлов читался бы config.yml из текущего каталога, если он там есть
+				args = append(args, "--config", writeFile(t, "empty.yml", ""))
+			}
+			args = append(args, tt.args...)
+
+			cfg, printConfig, err := Load(args)
+			if err != nil {
+				t.Fatalf("Load error: %v", err)
+			}
+			if printConfig {
+				t.Error("printConfig = true without --print-config")
+			}
+			if cfg.Listen.Port != tt.wantPort || cfg.Listen.DrainDelay != tt.wantDrain {
+				t.Errorf("listen = %s, %s, want %s, %s", cfg.Listen.Port, cfg.Listen.DrainDelay, tt.wantPort, tt.wantDrain)
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnvList(t *testing.T) {
+	first := writeFile(t, "first.yml", "listen:\n  port: 9000\n")
+	second := writeFile(t, "second.yml", "auth:\n  hmac_secret: "+testSecret+"\n")
+	t.Setenv(configFilesEnv, first+", "+second)
+	t.Setenv("TAGES_STORAGE_PASSWORD", "test-password")
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if cfg.Listen.Port != "9000" || cfg.Auth.HMACSecret != testSecret {
+		t.Errorf("files from %s were not applied: port %s", configFilesEnv, cfg.Listen.Port)
+	}
+}
+
+func TestLoadRepositoryFiles(t *testing.T) {
+	// config.yml без секрета и пароля не проходит проверку, вместе с примером для разработки - проходит
+	_, _, err := Load([]string{"--config", "../../config.yml"})
+	for _, want := range []string{"hmac_secret", "storage.password is required"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(config.yml) error = %v, want containing %q", err, want)
+		}
+	}
+	cfg, _, err := Load([]string{"--config", "../../config.yml", "--config", "../../config.dev.example.yml"})
+	if err != nil {
+		t.Fatalf("Load(config.yml, config.dev.example.yml) error: %v", err)
+	}
+	if cfg.Listen.Port != "8081" {
+		t.Errorf("listen.port = %s, want 8081 from config.yml", cfg.Listen.Port)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "missing explicit file",
+			args:    []string{"--config", filepath.Join(os.TempDir(), "missing", "config.yml")},
+			wantErr: "failed to open config file",
+		},
+		{
+			name:    "unknown key",
+			file:    "listen:\n  prot: 9000\n",
+			wantErr: "field prot not found",
+		},
+		{
+			name:    "invalid env value",
+			env:     map[string]string{"TAGES_LISTEN_DRAIN_DELAY": "soon"},
+			wantErr: "invalid value of TAGES_LISTEN_DRAIN_DELAY",
+		},
+		{
+			name:    "invalid flag value",
+			args:    []string{"--storage.pool.max_conns=many"},
+			wantErr: "invalid value",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"--listen.prot=9000"},
+			wantErr: "flag provided but not defined",
+		},
+		{
+			name: "secret and secret file",
+			env: map[string]string{
+				"TAGES_STORAGE_DSN":      "postgres://localhost/postgres",
+				"TAGES_STORAGE_DSN_FILE": "/run/secrets/dsn",
+			},
+			wantErr: "only one of TAGES_STORAGE_DSN and TAGES_STORAGE_DSN_FILE can be set",
+		},
+		{
+			name:    "missing password file",
+			args:    []string{"--storage.password_file", filepath.Join(os.TempDir(), "missing", "password")},
+			wantErr: "failed to read storage.password_file",
+		},
+		{
+			name:    "missing storage password",
+			env:     map[string]string{"TAGES_STORAGE_PASSWORD": ""},
+			wantErr: "storage.password is required",
+		},
+		{
+			name:    "dsn without password",
+			env:     map[string]string{"TAGES_STORAGE_PASSWORD": "", "TAGES_STORAGE_DSN": "postgres://app@db/app"},
+			wantErr: "storage.password is required",
+		},
+		{
+			name:    "drain delay longer than shutdown timeout",
+			file:    "listen:\n  drain_delay: 5s\n  shutdown_timeout: 5s\n",
+			wantErr: "listen.drain_delay (5s) must be less than listen.shutdown_timeout (5s)",
+		},
+		{
+			name:    "all validation errors at once",
+			file:    "listen:\n  port: http\nidempotency:\n  key_ttl: 0s\n",
+			wantErr: "listen.port must be a TCP port number, got \"http\"\nidempotency.key_ttl must be positive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredSecrets(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			args := append([]string{"--config", writeFile(t, "config.yml", tt.file)}, tt.args...)
+
+			_, _, err := Load(args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	t.Setenv("TAGES_AUTH_HMAC_SECRET_FILE", writeFile(t, "jwt", testSecret+"\n"))
+	// TAGES_STORAGE_PASSWORD_FILE задает поле storage.password_file, а не читает пароль как _FILE
+	t.Setenv("TAGES_STORAGE_PASSWORD_FILE", writeFile(t, "db_password", "p@ss:word\r\n"))
+
+	cfg, _, err := Load([]string{"--config", writeFile(t, "config.yml", "storage:\n  password: from-file\n")})
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if cfg.Auth.HMACSecret != testSecret {
+		t.Errorf("auth.hmac_secret = %q, want secret without trailing newline", cfg.Auth.HMACSecret)
+	}
+	if cfg.Storage.Password != "p@ss:word" {
+		t.Errorf("storage.password = %q, want value of storage.password_file", cfg.Storage.Password)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	t.Setenv("TAGES_AUTH_HMAC_SECRET", testSecret)
+	t.Setenv("TAGES_STORAGE_DSN", "postgres://app:hunter2@db/app")
+	cfg, printConfig, err := Load([]string{"--config", writeFile(t, "config.yml", ""), "--print-config"})
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if !printConfig {
+		t.Error("printConfig = false with --print-config")
+	}
+
+	var out bytes.Buffer
+	if err := Print(&out, cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{testSecret, "hunter2"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("Print output contains secret %q:\n%s", secret, out.String())
+		}
+	}
+	for _, want := range []string{"hmac_secret: '" + redacted + "'", "dsn: '" + redacted + "'", "port: \"8080\""} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Print output does not contain %q:\n%s", want, out.String())
+		}
+	}
+	// Пустой секрет выводится пустым, чтобы было видно, что он не задан
+	if !strings.Contains(out.String(), "password: \"\"") {
+		t.Errorf("Print output does not show empty password:\n%s", out.String())
+	}
+	if cfg.Auth.HMACSecret != testSecret {
+		t.Error("Print modified the configuration")
+	}
+}
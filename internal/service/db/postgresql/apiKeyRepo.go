@@ -0,0 +1,122 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/metrics"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/tracing"
+	"time"
+)
+
+// apiKeyColumns - колонки api_keys в порядке, который ожидает scanAPIKey
+const apiKeyColumns = `id, name, prefix, secret_hash, scopes, expires_at, last_used_at, revoked_at, created_by, created_at`
+
+type apiKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *apiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func scanAPIKey(row pgx.Row) (service.APIKeySrv, error) {
+	var key service.APIKeySrv
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.SecretHash, &key.Scopes,
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedBy, &key.CreatedAt)
+	return key, err
+}
+
+// Создание нового API-ключа
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key service.APIKeySrv) (service.APIKeySrv, error) {
+	defer metrics.ObserveQuery("api_key", "CreateAPIKey", time.Now())
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.CreateAPIKey")
+	defer span.End()
+
+	query := `INSERT INTO api_keys (name, prefix, secret_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + apiKeyColumns
+	created, err := scanAPIKey(r.db.QueryRow(ctx, query,
+		key.Name, key.Prefix, key.SecretHash, key.Scopes, key.ExpiresAt, key.CreatedBy))
+	if err != nil {
+		return created, wrapError(ctx, err, "Error creating api key:")
+	}
+	return created, nil
+}
+
+// GetAPIKeyByPrefix возвращает API-ключ по открытому префиксу, в том числе отозванный или истекший
+func (r *apiKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (service.APIKeySrv, error) {
+	defer metrics.ObserveQuery("api_key", "GetAPIKeyByPrefix", time.Now())
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.GetAPIKeyByPrefix")
+	defer span.End()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return key, errs.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return key, wrapError(ctx, err, "Error fetching api key by prefix:")
+	}
+	return key, nil
+}
+
+// Получение всех API-ключей, новые первыми
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context) ([]service.APIKeySrv, error) {
+	defer metrics.ObserveQuery("api_key", "ListAPIKeys", time.Now())
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.ListAPIKeys")
+	defer span.End()
+
+	rows, err := r.db.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id DESC`)
+	if err != nil {
+		return nil, wrapError(ctx, err, "Error querying api keys:")
+	}
+	defer rows.Close()
+
+	var keys []service.APIKeySrv
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, wrapError(ctx, err, "Error scanning api key:")
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, err, "Error iterating api keys:")
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв не меняет время первого
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	defer metrics.ObserveQuery("api_key", "RevokeAPIKey", time.Now())
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.RevokeAPIKey")
+	defer span.End()
+
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1`
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return wrapError(ctx, err, "Error revoking api key:")
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey отмечает время последнего использования ключа. Чтобы не писать в базу
+// на каждый запрос, время обновляется не чаще раза в минуту
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	defer metrics.ObserveQuery("api_key", "TouchAPIKey", time.Now())
+	ctx, span := tracing.Start(ctx, "apiKeyRepository.TouchAPIKey")
+	defer span.End()
+
+	query := `UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')`
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return wrapError(ctx, err, "Error updating api key last use:")
+	}
+	return nil
+}
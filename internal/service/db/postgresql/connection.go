@@ -3,66 +3,99 @@ package postgresql
 import (
 	"context"
 	"fmt"
-	"log"
+	"net/url"
+	"strconv"
 	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/tracing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"tages-task-go/internal/config"
 )
 
+// pingTimeout - время на одну попытку проверки соединения при запуске
+const pingTimeout = 5 * time.Second
+
 var dbPool *pgxpool.Pool
 
-func InitDB(logger *logging.Logger) *pgxpool.Pool {
-	cfg := config.GetConfig()
-	logger.Info("Connecting to PostgreSQL...")
+// dbURL - адрес базы, пароль из которого вырезается из ошибок пакета, см. redactError
+var dbURL *url.URL
 
-	// Формируем строку подключения
-	connStr := fmt.Sprintf(
-		"user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
-		cfg.Storage.Username,
-		cfg.Storage.Password,
-		cfg.Storage.Host,
-		cfg.Storage.Port,
-		cfg.Storage.Database,
-	)
+// InitDB создает пул соединений, дожидается доступности базы и применяет миграции
+func InitDB(logger *logging.Logger) (*pgxpool.Pool, error) {
+	cfg := config.GetConfig().Storage
 
-	// Формируем строку подключения для миграций
-	databaseURL := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Storage.Username,
-		cfg.Storage.Password,
-		cfg.Storage.Host,
-		cfg.Storage.Port,
-		cfg.Storage.Database,
-	)
+	// Строка подключения общая для пула и миграций. В журнал и ошибки она попадает только без пароля
+	databaseURL, err := connectionURL(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+	dbURL = databaseURL
+	logger.Infof("Connecting to PostgreSQL at %s...", databaseURL.Redacted())
 
 	// Создаем конфигурацию пула
-	poolConfig, err := pgxpool.ParseConfig(connStr)
+	poolConfig, err := pgxpool.ParseConfig(databaseURL.String())
 	if err != nil {
-		log.Fatalf("Failed to parse database configuration: %v", err)
+		return nil, fmt.Errorf("failed to parse database configuration: %w", redactError(err, databaseURL))
 	}
 
 	// Настраиваем пул соединений
-	poolConfig.MaxConns = 10
-	poolConfig.HealthCheckPeriod = time.Minute
+	poolConfig.MinConns = cfg.Pool.MinConns
+	poolConfig.MaxConns = cfg.Pool.MaxConns
+	poolConfig.MaxConnLifetime = cfg.Pool.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.Pool.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.Pool.HealthCheckPeriod
+	// statement_timeout задается только пулу: на миграции ограничение не распространяется
+	if cfg.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	// Каждый запрос к базе становится дочерним спаном трассировки запроса
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
 
 	// Инициализируем пул соединений
 	dbPool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
-		log.Fatalf("Failed to create a connection pool: %v", err)
+		return nil, fmt.Errorf("failed to create a connection pool: %w", redactError(err, databaseURL))
 	}
 
-	// Проверка соединения
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := dbPool.Ping(ctx); err != nil {
-		log.Fatalf("Database is unreachable: %v", err)
+	// База может подниматься дольше сервиса, например под docker-compose
+	if err := waitForDatabase(context.Background(), dbPool, cfg.Connect, logger); err != nil {
+		dbPool.Close()
+		return nil, redactError(err, databaseURL)
 	}
 
 	// Запуск миграций
-	RunMigrations(databaseURL)
+	if err := RunMigrations(databaseURL); err != nil {
+		dbPool.Close()
+		return nil, err
+	}
 
 	logger.Info("Connected to PostgreSQL")
-	return dbPool
+	return dbPool, nil
+}
+
+// waitForDatabase проверяет соединение, повторяя попытки с экспоненциально растущей паузой,
+// пока не истечет cfg.Timeout
+func waitForDatabase(ctx context.Context, pool *pgxpool.Pool, cfg config.ConnectRetryConfig, logger *logging.Logger) error {
+	deadline := time.Now().Add(cfg.Timeout)
+	backoff := cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := pool.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("database is unreachable after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warnf("Database is unreachable (attempt %d), retrying in %s: %v", attempt, backoff, redactError(err, dbURL))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, cfg.MaxBackoff)
+	}
 }
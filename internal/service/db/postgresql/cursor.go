@@ -0,0 +1,170 @@
+package postgresql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/models/money"
+	"time"
+)
+
+var (
+	// ErrInvalidCursor возвращается для курсора, который не удалось разобрать
+	ErrInvalidCursor = errs.New(errs.ErrValidation, "invalid cursor", nil)
+	// ErrCursorSortMismatch возвращается, если курсор получен для другого порядка сортировки
+	ErrCursorSortMismatch = errs.New(errs.ErrValidation, "cursor does not match the requested sort order", nil)
+)
+
+// sortColumn описывает колонку, по которой разрешена сортировка списка,
+// и тип, к которому приводится значение из курсора
+type sortColumn struct {
+	column string
+	cast   string
+}
+
+// productSortColumns - допустимые ключи сортировки продуктов
+var productSortColumns = map[string]sortColumn{
+	"id":    {column: "id", cast: "int"},
+	"name":  {column: "name", cast: "text"},
+	"price": {column: "price", cast: "numeric"},
+}
+
+// orderSortColumns - допустимые ключи сортировки заказов
+var orderSortColumns = map[string]sortColumn{
+	"id":          {column: "id", cast: "int"},
+	"created_at":  {column: "created_at", cast: "timestamptz"},
+	"total_price": {column: "total_price", cast: "numeric"},
+}
+
+// customerSortColumns - допустимые ключи сортировки покупателей
+var customerSortColumns = map[string]sortColumn{
+	"id":         {column: "id", cast: "int"},
+	"name":       {column: "name", cast: "text"},
+	"created_at": {column: "created_at", cast: "timestamptz"},
+}
+
+// cursor - позиция последней строки страницы: ключ сортировки, значение колонки сортировки и id.
+// Клиенту передается непрозрачной строкой base64url(JSON)
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    int    `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор и проверяет, что он выдан для того же порядка сортировки,
+// а значение приводится к типу колонки column: иначе ошибку приведения вернул бы драйвер
+func decodeCursor(token, sort string, column sortColumn) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return c, ErrInvalidCursor
+	}
+	if c.Sort != sort {
+		return c, ErrCursorSortMismatch
+	}
+	if !validCursorValue(c.Value, column) {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// validCursorValue проверяет, что значение из курсора имеет формат, в котором его записывают репозитории
+func validCursorValue(value string, column sortColumn) bool {
+	switch column.cast {
+	case "numeric":
+		_, err := money.ParseDecimal(value)
+		return err == nil
+	case "timestamptz":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	}
+	return true
+}
+
+// listSort - разобранный параметр sort: ключ, колонка и направление
+type listSort struct {
+	key        string
+	column     sortColumn
+	descending bool
+}
+
+// parseSort разбирает параметр sort вида "price" или "-price". Пустое значение означает сортировку по id
+func parseSort(sort string, columns map[string]sortColumn) (listSort, error) {
+	key := strings.TrimPrefix(sort, "-")
+	if key == "" {
+		key = "id"
+	}
+	column, ok := columns[key]
+	if !ok {
+		return listSort{}, errs.New(errs.ErrValidation, fmt.Sprintf("unsupported sort field %q", key), nil)
+	}
+	return listSort{key: key, column: column, descending: strings.HasPrefix(sort, "-")}, nil
+}
+
+// orderBy возвращает выражение ORDER BY. id добавляется последним, чтобы порядок был однозначным
+func (s listSort) orderBy() string {
+	direction := "ASC"
+	if s.descending {
+		direction = "DESC"
+	}
+	if s.key == "id" {
+		return "id " + direction
+	}
+	return s.column.column + " " + direction + ", id " + direction
+}
+
+// listQuery собирает условия WHERE и их параметры для выборки страницы
+type listQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg добавляет параметр запроса и возвращает его плейсхолдер
+func (q *listQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *listQuery) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// after добавляет условие keyset-пагинации: строки строго после позиции курсора
+func (q *listQuery) after(s listSort, c cursor) {
+	op := ">"
+	if s.descending {
+		op = "<"
+	}
+	if s.key == "id" {
+		q.where(fmt.Sprintf("id %s %s", op, q.arg(c.ID)))
+		return
+	}
+	q.where(fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+		s.column.column, op, q.arg(c.Value), s.column.cast, q.arg(c.ID)))
+}
+
+// build возвращает запрос с условиями, сортировкой и ограничением limit
+func (q *listQuery) build(selectFrom string, s listSort, limit int) string {
+	query := selectFrom
+	if len(q.conditions) > 0 {
+		query += " WHERE " + strings.Join(q.conditions, " AND ")
+	}
+	return query + " ORDER BY " + s.orderBy() + " LIMIT " + q.arg(limit)
+}
+
+// likePattern экранирует спецсимволы LIKE, чтобы подстрока искалась буквально
+func likePattern(substring string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(substring) + "%"
+}
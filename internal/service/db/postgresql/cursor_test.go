@@ -0,0 +1,161 @@
+package postgresql
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"tages-task-go/pkg/errs"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		cursor
+		column sortColumn
+	}{
+		{cursor: cursor{Sort: "", ID: 1}, column: productSortColumns["id"]},
+		{cursor: cursor{Sort: "-id", ID: 42}, column: productSortColumns["id"]},
+		{cursor: cursor{Sort: "name", Value: "Чай \"зеленый\" / 100 г", ID: 7}, column: productSortColumns["name"]},
+		{cursor: cursor{Sort: "-price", Value: "10.50", ID: 3}, column: productSortColumns["price"]},
+		{cursor: cursor{Sort: "created_at", Value: "2024-10-01T12:00:00.123456Z", ID: 9}, column: orderSortColumns["created_at"]},
+	}
+	for _, tt := range tests {
+		c := tt.cursor
+		token := encodeCursor(c)
+		got, err := decodeCursor(token, c.Sort, tt.column)
+		if err != nil {
+			t.Fatalf("decodeCursor(encodeCursor(%+v)) error: %v", c, err)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", c, got)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name    string
+		token   string
+		sort    string
+		column  sortColumn
+		wantErr error
+	}{
+		{name: "not base64", token: "!!!", wantErr: ErrInvalidCursor},
+		{name: "padded base64", token: base64.URLEncoding.EncodeToString([]byte(`{"i":1}`)), wantErr: ErrInvalidCursor},
+		{name: "not json", token: encode("not json"), wantErr: ErrInvalidCursor},
+		{name: "missing id", token: encode(`{"s":""}`), wantErr: ErrInvalidCursor},
+		{name: "negative id", token: encode(`{"i":-1}`), wantErr: ErrInvalidCursor},
+		{name: "id of wrong type", token: encode(`{"i":"1"}`), wantErr: ErrInvalidCursor},
+		{name: "other sort", token: encodeCursor(cursor{Sort: "name", Value: "a", ID: 1}), sort: "-name", wantErr: ErrCursorSortMismatch},
+		{name: "price not a number", token: encodeCursor(cursor{Sort: "-price", Value: "abc", ID: 1}), sort: "-price",
+			column: productSortColumns["price"], wantErr: ErrInvalidCursor},
+		{name: "price missing", token: encodeCursor(cursor{Sort: "price", ID: 1}), sort: "price",
+			column: productSortColumns["price"], wantErr: ErrInvalidCursor},
+		{name: "created_at not a time", token: encodeCursor(cursor{Sort: "created_at", Value: "yesterday", ID: 1}), sort: "created_at",
+			column: orderSortColumns["created_at"], wantErr: ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.token, tt.sort, tt.column)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodeCursor error = %v, want %v", err, tt.wantErr)
+			}
+			if !errors.Is(err, errs.ErrValidation) {
+				t.Errorf("decodeCursor error = %v, want errs.ErrValidation kind", err)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sort        string
+		wantOrderBy string
+		wantErr     bool
+	}{
+		{sort: "", wantOrderBy: "id ASC"},
+		{sort: "-id", wantOrderBy: "id DESC"},
+		{sort: "price", wantOrderBy: "price ASC, id ASC"},
+		{sort: "-name", wantOrderBy: "name DESC, id DESC"},
+		{sort: "stock", wantErr: true},
+		{sort: "name; DROP TABLE products", wantErr: true},
+	}
+	for _, tt := range tests {
+		s, err := parseSort(tt.sort, productSortColumns)
+		if tt.wantErr {
+			if !errors.Is(err, errs.ErrValidation) {
+				t.Errorf("parseSort(%q) error = %v, want errs.ErrValidation", tt.sort, err)
+			}
+			continue
+		}
+		if err != nil || s.orderBy() != tt.wantOrderBy {
+			t.Errorf("parseSort(%q).orderBy() = %q, %v, want %q", tt.sort, s.orderBy(), err, tt.wantOrderBy)
+		}
+	}
+}
+
+func TestListQueryAfter(t *testing.T) {
+	tests := []struct {
+		name      string
+		sort      string
+		cursor    cursor
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "by id",
+			sort:      "",
+			cursor:    cursor{ID: 10},
+			wantQuery: "SELECT id FROM products WHERE id > $1 ORDER BY id ASC LIMIT $2",
+			wantArgs:  []interface{}{10, 21},
+		},
+		{
+			name:      "by id descending",
+			sort:      "-id",
+			cursor:    cursor{Sort: "-id", ID: 10},
+			wantQuery: "SELECT id FROM products WHERE id < $1 ORDER BY id DESC LIMIT $2",
+			wantArgs:  []interface{}{10, 21},
+		},
+		{
+			name:      "by price descending",
+			sort:      "-price",
+			cursor:    cursor{Sort: "-price", Value: "10.50", ID: 3},
+			wantQuery: "SELECT id FROM products WHERE (price, id) < ($1::numeric, $2) ORDER BY price DESC, id DESC LIMIT $3",
+			wantArgs:  []interface{}{"10.50", 3, 21},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseSort(tt.sort, productSortColumns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var q listQuery
+			q.after(s, tt.cursor)
+			query := q.build("SELECT id FROM products", s, 21)
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(q.args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", q.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	tests := map[string]string{
+		"tea":      "%tea%",
+		"100%":     `%100\%%`,
+		"a_b":      `%a\_b%`,
+		`back\sl`:  `%back\\sl%`,
+		"":         "%%",
+		`%_\mixed`: `%\%\_\\mixed%`,
+	}
+	for input, want := range tests {
+		if got := likePattern(input); got != want {
+			t.Errorf("likePattern(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
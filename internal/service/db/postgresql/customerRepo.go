@@ -0,0 +1,174 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/metrics"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/tracing"
+	"time"
+)
+
+type customerRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCustomerRepository(db *pgxpool.Pool) *customerRepository {
+	return &customerRepository{db: db}
+}
+
+// customerError заменяет нарушение уникальности email на errs.ErrCustomerEmailTaken
+func (r *customerRepository) customerError(ctx context.Context, err error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		logging.FromContext(ctx).Warnf("Customer email is already taken: %s", pgErr.Detail)
+		return errs.ErrCustomerEmailTaken
+	}
+	return wrapError(ctx, err, msg)
+}
+
+// Создание нового покупателя
+func (r *customerRepository) CreateCustomer(ctx context.Context, customer service.CustomerSrv) (service.CustomerSrv, error) {
+	defer metrics.ObserveQuery("customer", "CreateCustomer", time.Now())
+	ctx, span := tracing.Start(ctx, "customerRepository.CreateCustomer")
+	defer span.End()
+
+	var created service.CustomerSrv
+	query := `INSERT INTO customers (name, email, phone) VALUES ($1, $2, $3)
+		RETURNING id, name, email, phone, created_at`
+	err := r.db.QueryRow(ctx, query, customer.Name, customer.Email, customer.Phone).
+		Scan(&created.ID, &created.Name, &created.Email, &created.Phone, &created.CreatedAt)
+	if err != nil {
+		return created, r.customerError(ctx, err, "Error creating customer:")
+	}
+	return created, nil
+}
+
+// Получение покупателя по ID
+func (r *customerRepository) GetCustomerByID(ctx context.Context, id int) (service.CustomerSrv, error) {
+	defer metrics.ObserveQuery("customer", "GetCustomerByID", time.Now())
+	ctx, span := tracing.Start(ctx, "customerRepository.GetCustomerByID")
+	defer span.End()
+
+	var customer service.CustomerSrv
+	query := `SELECT id, name, email, phone, created_at FROM customers WHERE id = $1`
+	err := r.db.QueryRow(ctx, query, id).
+		Scan(&customer.ID, &customer.Name, &customer.Email, &customer.Phone, &customer.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return customer, errs.ErrCustomerNotFound
+	}
+	if err != nil {
+		return customer, wrapError(ctx, err, "Error fetching customer by ID:")
+	}
+	return customer, nil
+}
+
+// Обновление покупателя целиком
+func (r *customerRepository) UpdateCustomer(ctx context.Context, customer service.CustomerSrv) (service.CustomerSrv, error) {
+	defer metrics.ObserveQuery("customer", "UpdateCustomer", time.Now())
+	ctx, span := tracing.Start(ctx, "customerRepository.UpdateCustomer")
+	defer span.End()
+
+	var updated service.CustomerSrv
+	query := `UPDATE customers SET name = $1, email = $2, phone = $3 WHERE id = $4
+		RETURNING id, name, email, phone, created_at`
+	err := r.db.QueryRow(ctx, query, customer.Name, customer.Email, customer.Phone, customer.ID).
+		Scan(&updated.ID, &updated.Name, &updated.Email, &updated.Phone, &updated.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return updated, errs.ErrCustomerNotFound
+	}
+	if err != nil {
+		return updated, r.customerError(ctx, err, "Error updating customer:")
+	}
+	return updated, nil
+}
+
+// Удаление покупателя. Покупателя, у которого есть заказы, удалить нельзя:
+// в этом случае возвращается errs.ErrCustomerInUse
+func (r *customerRepository) DeleteCustomer(ctx context.Context, id int) error {
+	defer metrics.ObserveQuery("customer", "DeleteCustomer", time.Now())
+	ctx, span := tracing.Start(ctx, "customerRepository.DeleteCustomer")
+	defer span.End()
+
+	query := `DELETE FROM customers WHERE id = $1`
+	tag, err := r.db.Exec(ctx, query, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		logging.FromContext(ctx).Warnf("Refusing to delete customer %d referenced by orders", id)
+		return errs.ErrCustomerInUse
+	}
+	if err != nil {
+		return wrapError(ctx, err, "Error deleting customer:")
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrCustomerNotFound
+	}
+	return nil
+}
+
+// ListCustomers возвращает страницу покупателей по фильтру, страницы выбираются по курсору
+func (r *customerRepository) ListCustomers(ctx context.Context, filter service.CustomerFilterSrv) (service.CustomerPageSrv, error) {
+	defer metrics.ObserveQuery("customer", "ListCustomers", time.Now())
+	ctx, span := tracing.Start(ctx, "customerRepository.ListCustomers")
+	defer span.End()
+
+	var page service.CustomerPageSrv
+	sortBy, err := parseSort(filter.Sort, customerSortColumns)
+	if err != nil {
+		return page, err
+	}
+
+	var q listQuery
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor, filter.Sort, sortBy.column)
+		if err != nil {
+			return page, err
+		}
+		q.after(sortBy, c)
+	}
+	if filter.NameContains != "" {
+		q.where("name ILIKE " + q.arg(likePattern(filter.NameContains)))
+	}
+	if filter.Email != "" {
+		q.where("lower(email) = lower(" + q.arg(filter.Email) + ")")
+	}
+
+	// Выбираем на одну строку больше, чтобы узнать, есть ли следующая страница
+	query := q.build(`SELECT id, name, email, phone, created_at FROM customers`, sortBy, filter.Limit+1)
+	rows, err := r.db.Query(ctx, query, q.args...)
+	if err != nil {
+		return page, wrapError(ctx, err, "Error querying customers:")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var customer service.CustomerSrv
+		err = rows.Scan(&customer.ID, &customer.Name, &customer.Email, &customer.Phone, &customer.CreatedAt)
+		if err != nil {
+			return page, wrapError(ctx, err, "Error scanning customer:")
+		}
+		page.Items = append(page.Items, customer)
+	}
+	if err = rows.Err(); err != nil {
+		return page, wrapError(ctx, err, "Error iterating customers:")
+	}
+
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		last := page.Items[len(page.Items)-1]
+		next := cursor{Sort: filter.Sort, ID: last.ID}
+		switch sortBy.key {
+		case "name":
+			next.Value = last.Name
+		case "created_at":
+			next.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		}
+		page.NextCursor = encodeCursor(next)
+	}
+	return page, nil
+}
@@ -0,0 +1,88 @@
+package postgresql
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"tages-task-go/internal/config"
+)
+
+// redactedPassword заменяет пароль в сообщениях журнала и ошибках
+const redactedPassword = "xxxxx"
+
+// connectionURL возвращает адрес базы в виде URL. Пользователь и пароль экранируются net/url,
+// поэтому символы @, /, : и % в пароле не ломают адрес. Пароль из storage.password
+// подставляется в storage.dsn, только если в самом DSN пароля нет. Так же дополняются
+// параметры TLS и application_name: указанные в DSN имеют приоритет
+func connectionURL(cfg config.StorageConfig) (*url.URL, error) {
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.Username, cfg.Password),
+		Host:   net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:   "/" + cfg.Database,
+	}
+	if cfg.DSN != "" {
+		var err error
+		u, err = url.Parse(cfg.DSN)
+		if err != nil {
+			// Ошибка url.Parse содержит исходную строку вместе с паролем
+			return nil, errors.New("storage.dsn is not a valid URL")
+		}
+		if _, hasPassword := u.User.Password(); u.User != nil && !hasPassword && cfg.Password != "" {
+			u.User = url.UserPassword(u.User.Username(), cfg.Password)
+		}
+	}
+
+	// Параметры понимают и pgx, и драйвер lib/pq, через который работает migrate
+	query := u.Query()
+	for key, value := range map[string]string{
+		"sslmode":          cfg.SSLMode,
+		"sslrootcert":      cfg.SSLRootCert,
+		"sslcert":          cfg.SSLCert,
+		"sslkey":           cfg.SSLKey,
+		"application_name": cfg.ApplicationName,
+	} {
+		if value != "" && !query.Has(key) {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u, nil
+}
+
+// redactError убирает из текста ошибки пароль из адреса u, как в исходном, так и в экранированном виде.
+// Драйверы и migrate могут включать строку подключения в свои ошибки
+func redactError(err error, u *url.URL) error {
+	if err == nil || u == nil {
+		return err
+	}
+	password, ok := u.User.Password()
+	if !ok || password == "" {
+		return err
+	}
+
+	message := err.Error()
+	escaped := strings.TrimPrefix(url.UserPassword("", password).String(), ":")
+	for _, secret := range []string{escaped, password} {
+		message = strings.ReplaceAll(message, secret, redactedPassword)
+	}
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{message: message, err: err}
+}
+
+// redactedError - ошибка с очищенным от пароля текстом. Исходная ошибка доступна через errors.Is и errors.As
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"net"
+	"strings"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/logging"
+)
+
+// SQLSTATE-коды PostgreSQL, которые требуют отдельной обработки
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	notNullViolation    = "23502"
+	checkViolation      = "23514"
+	invalidText         = "22P02"
+	serializationFail   = "40001"
+	deadlockDetected    = "40P01"
+)
+
+// wrapError логирует ошибку запроса и относит ее к одной из категорий errs.ErrNotFound, errs.ErrConflict,
+// errs.ErrValidation или errs.ErrUnavailable. Ошибки, которые не удалось классифицировать, возвращаются
+// без категории и трактуются транспортным слоем как внутренние
+func wrapError(ctx context.Context, err error, msg string) error {
+	logger := logging.FromContext(ctx)
+	err = redactError(err, dbURL)
+
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
+			pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
+		logger.Error(newErr) // Логируем детализированную ошибку
+		if kind, message := classifyPgError(pgErr); kind != nil {
+			return errs.New(kind, message, newErr)
+		}
+		return newErr
+	}
+
+	logger.Println(msg, err) // Логируем общую ошибку
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return errs.New(errs.ErrNotFound, "resource not found", err)
+	case isUnavailable(err):
+		return errs.New(errs.ErrUnavailable, "database is unavailable", err)
+	}
+	return err
+}
+
+// classifyPgError определяет категорию ошибки по ее SQLSTATE. Сообщения фиксированы: Message и Detail
+// от PostgreSQL содержат имена таблиц, колонок и ограничений, а также значения из чужих строк,
+// например email из Key (email)=(...) already exists, поэтому попадают только в Err и логи
+func classifyPgError(pgErr *pgconn.PgError) (error, string) {
+	switch pgErr.Code {
+	case uniqueViolation:
+		return errs.ErrConflict, "resource already exists"
+	case foreignKeyViolation:
+		return errs.ErrConflict, "resource is referenced by or references a missing resource"
+	case serializationFail, deadlockDetected:
+		return errs.ErrConflict, "concurrent update detected, retry the request"
+	case notNullViolation:
+		return errs.ErrValidation, "a required value is missing"
+	case checkViolation:
+		return errs.ErrValidation, "a value violates a data constraint"
+	case invalidText:
+		return errs.ErrValidation, "a value has an invalid format"
+	}
+
+	switch {
+	case strings.HasPrefix(pgErr.Code, "22"): // data exception
+		return errs.ErrValidation, "a value is invalid or out of range"
+	case strings.HasPrefix(pgErr.Code, "08"), // connection exception
+		strings.HasPrefix(pgErr.Code, "53"),  // insufficient resources
+		strings.HasPrefix(pgErr.Code, "57P"): // operator intervention
+		return errs.ErrUnavailable, "database is unavailable"
+	}
+	return nil, ""
+}
+
+// isUnavailable сообщает, вызвана ли ошибка недоступностью базы данных
+func isUnavailable(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		pgconn.Timeout(err) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
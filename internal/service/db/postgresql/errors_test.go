@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+	"tages-task-go/pkg/errs"
+	"testing"
+)
+
+func TestWrapErrorClassifiesPgErrors(t *testing.T) {
+	tests := []struct {
+		code     string
+		wantKind error
+	}{
+		{code: uniqueViolation, wantKind: errs.ErrConflict},
+		{code: foreignKeyViolation, wantKind: errs.ErrConflict},
+		{code: serializationFail, wantKind: errs.ErrConflict},
+		{code: notNullViolation, wantKind: errs.ErrValidation},
+		{code: checkViolation, wantKind: errs.ErrValidation},
+		{code: invalidText, wantKind: errs.ErrValidation},
+		{code: "22003", wantKind: errs.ErrValidation}, // numeric_value_out_of_range
+		{code: "08006", wantKind: errs.ErrUnavailable},
+		{code: "57P01", wantKind: errs.ErrUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			pgErr := &pgconn.PgError{Code: tt.code, Message: "value from row", Detail: "Key (email)=(a@b.c) already exists"}
+			err := wrapError(context.Background(), fmt.Errorf("query: %w", pgErr), "Error:")
+			if !errors.Is(err, tt.wantKind) {
+				t.Fatalf("wrapError = %v, want kind %v", err, tt.wantKind)
+			}
+			var domainErr *errs.Error
+			if !errors.As(err, &domainErr) {
+				t.Fatalf("wrapError returned %T, want *errs.Error", err)
+			}
+			// Клиенту уходит только Message: детали PostgreSQL остаются в Err
+			if strings.Contains(domainErr.Message, "a@b.c") || strings.Contains(domainErr.Message, "value from row") {
+				t.Errorf("message %q leaks PostgreSQL details", domainErr.Message)
+			}
+		})
+	}
+}
+
+func TestWrapErrorKeepsDomainErrors(t *testing.T) {
+	if err := wrapError(context.Background(), errs.ErrOrderNotFound, "Error:"); err != errs.ErrOrderNotFound {
+		t.Errorf("wrapError(ErrOrderNotFound) = %v, want it unchanged", err)
+	}
+	if err := wrapError(context.Background(), pgx.ErrNoRows, "Error:"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("wrapError(ErrNoRows) = %v, want ErrNotFound kind", err)
+	}
+	unknown := errors.New("boom")
+	if err := wrapError(context.Background(), unknown, "Error:"); err != unknown {
+		t.Errorf("wrapError(unknown) = %v, want it unchanged", err)
+	}
+}
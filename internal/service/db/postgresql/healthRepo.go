@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoMigrations возвращается, если в базе нет записи о примененных миграциях
+var ErrNoMigrations = errors.New("no migrations have been applied")
+
+type healthRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHealthRepository(db *pgxpool.Pool) *healthRepository {
+	return &healthRepository{db: db}
+}
+
+// Ping проверяет, что из пула можно получить соединение и база отвечает. Ошибки подключения
+// попадают в журнал, поэтому пароль из них вырезается
+func (r *healthRepository) Ping(ctx context.Context) error {
+	return redactError(r.db.Ping(ctx), dbURL)
+}
+
+// MigrationVersion возвращает версию схемы, записанную golang-migrate, и признак прерванной миграции
+func (r *healthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := r.db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, ErrNoMigrations
+	}
+	if err != nil {
+		return 0, false, redactError(err, dbURL)
+	}
+	return uint(version), dirty, nil
+}
+
+// LatestMigrationVersion возвращает номер последней миграции из каталога миграций сервиса
+func (r *healthRepository) LatestMigrationVersion() (uint, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	var latest uint
+	for _, entry := range entries {
+		prefix, _, found := strings.Cut(entry.Name(), "_")
+		if !found || !strings.HasSuffix(entry.Name(), ".up.sql") {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migration files found in %s", migrationsDir)
+	}
+	return latest, nil
+}
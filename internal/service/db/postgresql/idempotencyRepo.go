@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/metrics"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/tracing"
+	"time"
+)
+
+// ErrIdempotencyKeyBusy возвращается, если ключ идемпотентности освободили между попыткой
+// его занять и чтением сохраненной записи
+var ErrIdempotencyKeyBusy = errs.New(errs.ErrConflict, "idempotency key is being processed, retry the request", nil)
+
+type idempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) *idempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// ReserveIdempotencyKey занимает ключ для нового запроса. Истекший ключ занимается заново.
+// Если ключ уже занят и не истек, возвращается существующая запись и reserved == false
+func (r *idempotencyRepository) ReserveIdempotencyKey(ctx context.Context, key service.IdempotencyKeySrv) (service.IdempotencyKeySrv, bool, error) {
+	defer metrics.ObserveQuery("idempotency", "ReserveIdempotencyKey", time.Now())
+	ctx, span := tracing.Start(ctx, "idempotencyRepository.ReserveIdempotencyKey")
+	defer span.End()
+
+	query := `INSERT INTO idempotency_keys (scope, key, request_hash, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_headers = NULL,
+			response_body = NULL, created_at = now(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()
+		RETURNING created_at`
+	err := r.db.QueryRow(ctx, query, key.Scope, key.Key, key.RequestHash, key.ExpiresAt).Scan(&key.CreatedAt)
+	if err == nil {
+		return key, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return key, false, wrapError(ctx, err, "Error reserving idempotency key:")
+	}
+
+	// Ключ занят действующей записью: читаем ее, чтобы сравнить запрос или вернуть ответ
+	var existing service.IdempotencyKeySrv
+	var statusCode *int
+	query = `SELECT scope, key, request_hash, status_code, response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE scope = $1 AND key = $2`
+	err = r.db.QueryRow(ctx, query, key.Scope, key.Key).Scan(&existing.Scope, &existing.Key, &existing.RequestHash,
+		&statusCode, &existing.ResponseHeaders, &existing.ResponseBody, &existing.CreatedAt, &existing.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return key, false, ErrIdempotencyKeyBusy
+	}
+	if err != nil {
+		return key, false, wrapError(ctx, err, "Error fetching idempotency key:")
+	}
+	if statusCode != nil {
+		existing.StatusCode = *statusCode
+	}
+	return existing, false, nil
+}
+
+// CompleteIdempotencyKey сохраняет ответ на запрос, занявший ключ
+func (r *idempotencyRepository) CompleteIdempotencyKey(ctx context.Context, key service.IdempotencyKeySrv) error {
+	defer metrics.ObserveQuery("idempotency", "CompleteIdempotencyKey", time.Now())
+	ctx, span := tracing.Start(ctx, "idempotencyRepository.CompleteIdempotencyKey")
+	defer span.End()
+
+	query := `UPDATE idempotency_keys SET status_code = $3, response_headers = $4, response_body = $5
+		WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+	_, err := r.db.Exec(ctx, query, key.Scope, key.Key, key.StatusCode, key.ResponseHeaders, key.ResponseBody)
+	if err != nil {
+		return wrapError(ctx, err, "Error saving idempotent response:")
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey освобождает ключ, ответ на который не был сохранен, чтобы запрос можно было повторить
+func (r *idempotencyRepository) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	defer metrics.ObserveQuery("idempotency", "DeleteIdempotencyKey", time.Now())
+	ctx, span := tracing.Start(ctx, "idempotencyRepository.DeleteIdempotencyKey")
+	defer span.End()
+
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+	if _, err := r.db.Exec(ctx, query, scope, key); err != nil {
+		return wrapError(ctx, err, "Error releasing idempotency key:")
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys удаляет истекшие ключи вместе с сохраненными ответами и возвращает их количество
+func (r *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	defer metrics.ObserveQuery("idempotency", "DeleteExpiredIdempotencyKeys", time.Now())
+	ctx, span := tracing.Start(ctx, "idempotencyRepository.DeleteExpiredIdempotencyKeys")
+	defer span.End()
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, wrapError(ctx, err, "Error deleting expired idempotency keys:")
+	}
+	return tag.RowsAffected(), nil
+}
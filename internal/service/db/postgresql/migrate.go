@@ -1,30 +1,39 @@
 package postgresql
 
 import (
+	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
 )
 
-func RunMigrations(databaseURL string) {
+// migrationsDir - каталог с файлами миграций относительно рабочего каталога сервиса
+const migrationsDir = "internal/service/db/postgresql/migrations"
+
+// RunMigrations применяет миграции к базе по адресу databaseURL
+func RunMigrations(databaseURL *url.URL) error {
 	wd, err := os.Getwd()
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to locate migrations: %w", err)
 	}
 
-	sourceURL := "file://" + wd + "/internal/service/db/postgresql/migrations"
+	sourceURL := "file://" + filepath.Join(wd, migrationsDir)
 	m, err := migrate.New(
 		sourceURL,
-		databaseURL)
+		databaseURL.String())
 	if err != nil {
-		log.Fatalf("Failed to initialize migrations: %v", err)
+		return fmt.Errorf("failed to initialize migrations: %w", redactError(err, databaseURL))
 	}
+	defer m.Close()
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("Failed to run migrations: %v", err)
+		return fmt.Errorf("failed to run migrations: %w", redactError(err, databaseURL))
 	}
 
 	log.Println("Database migrations successfully applied")
+	return nil
 }
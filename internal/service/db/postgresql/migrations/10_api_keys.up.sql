@@ -0,0 +1,15 @@
+-- Ключи доступа для межсервисных клиентов. Секрет ключа не хранится, только его SHA-256;
+-- ключ находится по открытому префиксу
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           SERIAL PRIMARY KEY,
+    name         TEXT        NOT NULL,
+    prefix       TEXT        NOT NULL UNIQUE,
+    secret_hash  TEXT        NOT NULL,
+    scopes       TEXT[]      NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_by   TEXT        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,17 @@
+ALTER TABLE orders
+    ADD COLUMN product_id INT REFERENCES products (id),
+    ADD COLUMN quantity   INT NOT NULL DEFAULT 0;
+
+-- Возвращаем в заказ первую из его позиций
+UPDATE orders o
+SET product_id = i.product_id,
+    quantity   = i.quantity
+FROM (SELECT DISTINCT ON (order_id) order_id, product_id, quantity
+      FROM order_items
+      ORDER BY order_id, id) i
+WHERE i.order_id = o.id;
+
+ALTER TABLE orders
+    ALTER COLUMN quantity DROP DEFAULT;
+
+DROP TABLE IF EXISTS order_items;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS order_items
+(
+    id         SERIAL PRIMARY KEY,
+    order_id   INT            NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id INT            NOT NULL REFERENCES products (id),
+    quantity   INT            NOT NULL,
+    price      NUMERIC(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id);
+
+-- Переносим существующие однопозиционные заказы в order_items
+INSERT INTO order_items (order_id, product_id, quantity, price)
+SELECT id, product_id, quantity, ROUND(total_price / quantity, 2)
+FROM orders
+WHERE product_id IS NOT NULL
+  AND quantity > 0;
+
+ALTER TABLE orders
+    DROP COLUMN product_id,
+    DROP COLUMN quantity;
@@ -0,0 +1,15 @@
+ALTER TABLE orders
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded'));
+
+CREATE TABLE IF NOT EXISTS order_status_history
+(
+    id          SERIAL PRIMARY KEY,
+    order_id    INT         NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status TEXT        NOT NULL,
+    to_status   TEXT        NOT NULL,
+    changed_by  TEXT        NOT NULL,
+    changed_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history (order_id);
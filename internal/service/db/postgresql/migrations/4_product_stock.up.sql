@@ -0,0 +1,6 @@
+-- Существующие товары получают остаток 0: до учета остатков он нигде не хранился, и угадывать его нельзя.
+-- До открытия продаж оператор задает реальные остатки, иначе заказы на эти товары будут отклоняться
+-- из-за нехватки товара:
+--   UPDATE products SET stock = <остаток> WHERE id = <id>;
+ALTER TABLE products
+    ADD COLUMN stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0);
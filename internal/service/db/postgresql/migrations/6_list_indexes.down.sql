@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS order_items_product_id_idx;
+DROP INDEX IF EXISTS orders_total_price_id_idx;
+DROP INDEX IF EXISTS orders_created_at_id_idx;
+DROP INDEX IF EXISTS products_price_id_idx;
+DROP INDEX IF EXISTS products_name_id_idx;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,9 @@
+ALTER TABLE orders
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Индексы для keyset-пагинации по поддерживаемым полям сортировки
+CREATE INDEX IF NOT EXISTS products_name_id_idx ON products (name, id);
+CREATE INDEX IF NOT EXISTS products_price_id_idx ON products (price, id);
+CREATE INDEX IF NOT EXISTS orders_created_at_id_idx ON orders (created_at, id);
+CREATE INDEX IF NOT EXISTS orders_total_price_id_idx ON orders (total_price, id);
+CREATE INDEX IF NOT EXISTS order_items_product_id_idx ON order_items (product_id);
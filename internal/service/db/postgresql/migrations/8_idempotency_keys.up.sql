@@ -0,0 +1,16 @@
+-- Ключи идемпотентности запросов. Пока запрос обрабатывается, status_code равен NULL,
+-- после завершения сохраняется ответ, который возвращается на повторы с тем же ключом
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    scope            TEXT        NOT NULL,
+    key              TEXT        NOT NULL,
+    request_hash     TEXT        NOT NULL,
+    status_code      INT,
+    response_headers JSONB,
+    response_body    BYTEA,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at       TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
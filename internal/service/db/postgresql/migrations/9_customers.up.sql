@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS customers
+(
+    id         SERIAL PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    email      TEXT        NOT NULL,
+    phone      TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS customers_email_key ON customers (lower(email));
+CREATE INDEX IF NOT EXISTS customers_name_id_idx ON customers (name, id);
+CREATE INDEX IF NOT EXISTS customers_created_at_id_idx ON customers (created_at, id);
+
+-- Заказы, созданные до появления покупателей, остаются без владельца
+ALTER TABLE orders
+    ADD COLUMN customer_id INT REFERENCES customers (id);
+
+CREATE INDEX IF NOT EXISTS orders_customer_id_idx ON orders (customer_id);
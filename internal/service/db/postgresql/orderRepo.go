@@ -4,10 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"sort"
+	"tages-task-go/pkg/errs"
 	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/metrics"
+	"tages-task-go/pkg/models/money"
 	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/tracing"
+	"time"
 )
 
 //type OrderRepository interface {
@@ -17,99 +24,348 @@ import (
 //}
 
 type orderRepository struct {
-	db     *pgxpool.Pool
-	logger *logging.Logger
+	db *pgxpool.Pool
 }
 
-func NewOrderRepository(db *pgxpool.Pool, logger *logging.Logger) *orderRepository {
-	return &orderRepository{db: db, logger: logger}
+func NewOrderRepository(db *pgxpool.Pool) *orderRepository {
+	return &orderRepository{db: db}
 }
 
-// Получение всех заказов
-func (r *orderRepository) GetAllOrders(ctx context.Context) ([]*service.OrderSrv, error) {
-	rows, err := r.db.Query(ctx, "SELECT id, product_id, quantity, total_price FROM orders")
+// ListOrders возвращает страницу заказов по фильтру вместе с их позициями.
+// Страницы выбираются по курсору (keyset-пагинация)
+func (r *orderRepository) ListOrders(ctx context.Context, filter service.OrderFilterSrv) (service.OrderPageSrv, error) {
+	defer metrics.ObserveQuery("order", "ListOrders", time.Now())
+	ctx, span := tracing.Start(ctx, "orderRepository.ListOrders")
+	defer span.End()
+
+	var page service.OrderPageSrv
+	sortBy, err := parseSort(filter.Sort, orderSortColumns)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-				pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			r.logger.Error(newErr)
-			return nil, newErr
+		return page, err
+	}
+
+	var q listQuery
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor, filter.Sort, sortBy.column)
+		if err != nil {
+			return page, err
 		}
-		r.logger.Println("Error querying orders:", err)
-		return nil, err
+		q.after(sortBy, c)
+	}
+	if filter.CustomerID != 0 {
+		q.where("customer_id = " + q.arg(filter.CustomerID))
+	}
+	if filter.ProductID != 0 {
+		q.where("EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = orders.id AND i.product_id = " +
+			q.arg(filter.ProductID) + ")")
+	}
+	if filter.Status != "" {
+		q.where("status = " + q.arg(filter.Status))
+	}
+	if filter.CreatedFrom != nil {
+		q.where("created_at >= " + q.arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		q.where("created_at < " + q.arg(*filter.CreatedTo))
+	}
+
+	// Выбираем на одну строку больше, чтобы узнать, есть ли следующая страница
+	query := q.build(`SELECT id, COALESCE(customer_id, 0), status, total_price, currency, created_at FROM orders`, sortBy, filter.Limit+1)
+	rows, err := r.db.Query(ctx, query, q.args...)
+	if err != nil {
+		return page, wrapError(ctx, err, "Error querying orders:")
 	}
 	defer rows.Close()
 
-	var orders []*service.OrderSrv
 	for rows.Next() {
 		// Инициализируем переменную order перед каждой итерацией
 		order := &service.OrderSrv{}
-		err = rows.Scan(&order.ID, &order.ProductID, &order.Quantity, &order.TotalPrice)
+		err = rows.Scan(&order.ID, &order.CustomerID, &order.Status, &order.TotalPrice.Amount, &order.TotalPrice.Currency, &order.CreatedAt)
 		if err != nil {
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-					pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-				r.logger.Error(newErr)
-				return nil, newErr
-			}
-			r.logger.Println("Error scanning order:", err)
-			return nil, err
+			return page, wrapError(ctx, err, "Error scanning order:")
+		}
+		page.Items = append(page.Items, order)
+	}
+	if err = rows.Err(); err != nil {
+		return page, wrapError(ctx, err, "Error iterating orders:")
+	}
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		last := page.Items[len(page.Items)-1]
+		next := cursor{Sort: filter.Sort, ID: last.ID}
+		switch sortBy.key {
+		case "created_at":
+			next.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		case "total_price":
+			next.Value = last.TotalPrice.Amount.String()
+		}
+		page.NextCursor = encodeCursor(next)
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	// Загружаем позиции всех заказов страницы одним запросом
+	ids := make([]int, 0, len(page.Items))
+	ordersByID := make(map[int]*service.OrderSrv, len(page.Items))
+	for _, order := range page.Items {
+		ids = append(ids, order.ID)
+		ordersByID[order.ID] = order
+	}
+	items, err := r.getOrderItems(ctx, ids)
+	if err != nil {
+		return page, err
+	}
+	for _, item := range items {
+		if order, ok := ordersByID[item.OrderID]; ok {
+			order.Items = append(order.Items, item)
 		}
-		orders = append(orders, order)
 	}
 
-	return orders, nil
+	return page, nil
 }
 
 // Получение заказа по ID
 func (r *orderRepository) GetOrderByID(ctx context.Context, id int) (*service.OrderSrv, error) {
+	defer metrics.ObserveQuery("order", "GetOrderByID", time.Now())
+	ctx, span := tracing.Start(ctx, "orderRepository.GetOrderByID")
+	defer span.End()
+
 	var order service.OrderSrv
-	err := r.db.QueryRow(ctx, "SELECT id, product_id, quantity, total_price FROM orders WHERE id=$1", id).
-		Scan(&order.ID, &order.ProductID, &order.Quantity, &order.TotalPrice)
+	err := r.db.QueryRow(ctx,
+		"SELECT id, COALESCE(customer_id, 0), status, total_price, currency, created_at FROM orders WHERE id=$1", id).
+		Scan(&order.ID, &order.CustomerID, &order.Status, &order.TotalPrice.Amount, &order.TotalPrice.Currency, &order.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errs.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, wrapError(ctx, err, "Error fetching order by ID:")
+	}
+
+	order.Items, err = r.getOrderItems(ctx, []int{order.ID})
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-				pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			r.logger.Error(newErr) // Логируем детализированную ошибку
-			return nil, newErr
-		}
-		r.logger.Println("Error fetching order by ID:", err) // Логируем общую ошибку
 		return nil, err
 	}
 	return &order, nil
 }
 
-// Создание нового заказа с автоматическим расчетом total_price
-func (r *orderRepository) CreateOrder(ctx context.Context, order *service.OrderSrv) error {
-	// Получаем цену товара
-	var productPrice float64
-	err := r.db.QueryRow(ctx, "SELECT price FROM products WHERE id=$1", order.ProductID).Scan(&productPrice)
+// Создание нового заказа с автоматическим расчетом total_price.
+// Заголовок заказа и все его позиции вставляются в одной транзакции, цена каждой позиции
+// берется из products.price, а остатки товаров списываются условным UPDATE в той же транзакции.
+// Если какого-то товара не хватает, возвращается *errs.InsufficientStockError.
+// Возвращается сохраненный заказ с присвоенным ID и рассчитанными ценами
+func (r *orderRepository) CreateOrder(ctx context.Context, order *service.OrderSrv) (*service.OrderSrv, error) {
+	defer metrics.ObserveQuery("order", "CreateOrder", time.Now())
+	ctx, span := tracing.Start(ctx, "orderRepository.CreateOrder")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, wrapError(ctx, err, "Error starting order transaction:")
+	}
+	defer tx.Rollback(ctx)
+
+	// Суммируем количество по каждому товару и списываем остатки в порядке возрастания id,
+	// чтобы параллельные заказы блокировали строки products в одном порядке и не ловили deadlock
+	quantities := make(map[int]int)
+	for _, item := range order.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	productIDs := make([]int, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+
+	prices := make(map[int]money.Money, len(productIDs))
+	for _, productID := range productIDs {
+		var price money.Money
+		err = tx.QueryRow(ctx,
+			"UPDATE products SET stock = stock - $1 WHERE id = $2 AND stock >= $1 RETURNING price, currency",
+			quantities[productID], productID).Scan(&price.Amount, &price.Currency)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.stockError(ctx, tx, productID, quantities[productID])
+		}
+		if err != nil {
+			return nil, wrapError(ctx, err, "Error reserving product stock for order:")
+		}
+		prices[productID] = price
+	}
+
+	// Рассчитываем общую стоимость заказа в точной десятичной арифметике.
+	// Все позиции заказа должны быть в одной валюте
+	totalPrice := money.Zero(prices[order.Items[0].ProductID].Currency)
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.Price = prices[item.ProductID]
+		lineTotal, err := item.Price.Mul(item.Quantity)
+		if err != nil {
+			logging.FromContext(ctx).Println("Error calculating order line total:", err)
+			return nil, errs.New(errs.ErrValidation, "order line total is out of range", err)
+		}
+		if totalPrice, err = totalPrice.Add(lineTotal); err != nil {
+			logging.FromContext(ctx).Println("Error calculating order total:", err)
+			return nil, errs.New(errs.ErrValidation, "order items must share one currency and fit the price range", err)
+		}
+	}
+
+	// Вставляем заголовок заказа. Заказ без покупателя хранится с customer_id = NULL
+	err = tx.QueryRow(ctx,
+		`INSERT INTO orders (customer_id, status, total_price, currency) VALUES (NULLIF($1, 0), $2, $3, $4)
+		RETURNING id, COALESCE(customer_id, 0), status, total_price, currency, created_at`,
+		order.CustomerID, order.Status, totalPrice.Amount, totalPrice.Currency).
+		Scan(&order.ID, &order.CustomerID, &order.Status, &order.TotalPrice.Amount, &order.TotalPrice.Currency, &order.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		logging.FromContext(ctx).Println("Customer not found for order:", order.CustomerID)
+		return nil, errs.New(errs.ErrValidation, fmt.Sprintf("customer %d does not exist", order.CustomerID), nil)
+	}
+	if err != nil {
+		return nil, wrapError(ctx, err, "Error creating order:")
+	}
+
+	// Вставляем позиции заказа
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderID = order.ID
+		err = tx.QueryRow(ctx,
+			"INSERT INTO order_items (order_id, product_id, quantity, price) VALUES ($1, $2, $3, $4) RETURNING id",
+			item.OrderID, item.ProductID, item.Quantity, item.Price.Amount).Scan(&item.ID)
+		if err != nil {
+			return nil, wrapError(ctx, err, "Error creating order item:")
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, wrapError(ctx, err, "Error committing order transaction:")
+	}
+	return order, nil
+}
+
+// stockError выясняет, почему не удалось списать остаток товара: товара нет или его не хватает
+func (r *orderRepository) stockError(ctx context.Context, tx pgx.Tx, productID, requested int) error {
+	var available int
+	err := tx.QueryRow(ctx, "SELECT stock FROM products WHERE id=$1", productID).Scan(&available)
+	if errors.Is(err, pgx.ErrNoRows) {
+		logging.FromContext(ctx).Println("Product not found for order item:", productID)
+		return errs.New(errs.ErrValidation, fmt.Sprintf("product %d does not exist", productID), nil)
+	}
+	if err != nil {
+		return wrapError(ctx, err, "Error fetching product stock for order:")
+	}
+	logging.FromContext(ctx).Warnf("Insufficient stock for product %d: requested %d, available %d", productID, requested, available)
+	stockErr := &errs.InsufficientStockError{ProductID: productID, Requested: requested, Available: available}
+	return errs.New(errs.ErrConflict, stockErr.Error(), stockErr)
+}
+
+// UpdateOrderStatus переводит заказ из статуса FromStatus в ToStatus и записывает переход в историю.
+// Если статус заказа к этому моменту уже изменился, возвращается errs.ErrStatusConflict
+func (r *orderRepository) UpdateOrderStatus(ctx context.Context, transition *service.OrderTransitionSrv) error {
+	defer metrics.ObserveQuery("order", "UpdateOrderStatus", time.Now())
+	ctx, span := tracing.Start(ctx, "orderRepository.UpdateOrderStatus")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return wrapError(ctx, err, "Error starting order status transaction:")
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "UPDATE orders SET status=$1 WHERE id=$2 AND status=$3",
+		transition.ToStatus, transition.OrderID, transition.FromStatus)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-				pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			r.logger.Error(newErr) // Логируем детализированную ошибку
-			return newErr
+		return wrapError(ctx, err, "Error updating order status:")
+	}
+	if tag.RowsAffected() == 0 {
+		logging.FromContext(ctx).Println("Order status changed concurrently:", transition.OrderID)
+		return errs.ErrStatusConflict
+	}
+
+	// Отмененный заказ и заказ, возвращенный до отгрузки, возвращают зарезервированные товары на склад.
+	// После доставки товар у покупателя: вернувшийся товар оператор оприходует сам после проверки
+	if restoresStock(transition) {
+		_, err = tx.Exec(ctx,
+			`UPDATE products p SET stock = p.stock + i.quantity
+			FROM (SELECT product_id, SUM(quantity) AS quantity FROM order_items WHERE order_id=$1 GROUP BY product_id) i
+			WHERE p.id = i.product_id`, transition.OrderID)
+		if err != nil {
+			return wrapError(ctx, err, "Error restoring product stock:")
 		}
-		r.logger.Println("Error fetching product price for order:", err) // Логируем общую ошибку
-		return errors.New("product not found")
 	}
 
-	// Рассчитываем общую стоимость заказа
-	totalPrice := productPrice * float64(order.Quantity)
+	err = tx.QueryRow(ctx,
+		`INSERT INTO order_status_history (order_id, from_status, to_status, changed_by)
+		VALUES ($1, $2, $3, $4) RETURNING id, changed_at`,
+		transition.OrderID, transition.FromStatus, transition.ToStatus, transition.ChangedBy).
+		Scan(&transition.ID, &transition.ChangedAt)
+	if err != nil {
+		return wrapError(ctx, err, "Error recording order status history:")
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return wrapError(ctx, err, "Error committing order status transaction:")
+	}
+	return nil
+}
+
+// restoresStock сообщает, возвращает ли переход товары заказа на склад
+func restoresStock(transition *service.OrderTransitionSrv) bool {
+	return transition.ToStatus == "cancelled" || (transition.ToStatus == "refunded" && transition.FromStatus == "paid")
+}
 
-	// Вставляем новый заказ
-	_, err = r.db.Exec(ctx, "INSERT INTO orders (product_id, quantity, total_price) VALUES ($1, $2, $3)",
-		order.ProductID, order.Quantity, totalPrice)
+// GetOrderTransitions возвращает историю изменения статусов заказа
+func (r *orderRepository) GetOrderTransitions(ctx context.Context, orderID int) ([]service.OrderTransitionSrv, error) {
+	defer metrics.ObserveQuery("order", "GetOrderTransitions", time.Now())
+	ctx, span := tracing.Start(ctx, "orderRepository.GetOrderTransitions")
+	defer span.End()
 
+	rows, err := r.db.Query(ctx,
+		`SELECT id, order_id, from_status, to_status, changed_by, changed_at
+		FROM order_status_history WHERE order_id=$1 ORDER BY changed_at, id`, orderID)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-				pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			r.logger.Error(newErr) // Логируем детализированную ошибку
-			return newErr
+		return nil, wrapError(ctx, err, "Error querying order status history:")
+	}
+	defer rows.Close()
+
+	var transitions []service.OrderTransitionSrv
+	for rows.Next() {
+		var transition service.OrderTransitionSrv
+		err = rows.Scan(&transition.ID, &transition.OrderID, &transition.FromStatus, &transition.ToStatus,
+			&transition.ChangedBy, &transition.ChangedAt)
+		if err != nil {
+			return nil, wrapError(ctx, err, "Error scanning order status history:")
 		}
-		r.logger.Println("Error creating order:", err) // Логируем общую ошибку
+		transitions = append(transitions, transition)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, err, "Error iterating order status history:")
+	}
+	return transitions, nil
+}
+
+// getOrderItems возвращает позиции указанных заказов
+func (r *orderRepository) getOrderItems(ctx context.Context, orderIDs []int) ([]service.OrderItemSrv, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT i.id, i.order_id, i.product_id, i.quantity, i.price, o.currency
+		FROM order_items i JOIN orders o ON o.id = i.order_id
+		WHERE i.order_id = ANY($1) ORDER BY i.order_id, i.id`,
+		orderIDs)
+	if err != nil {
+		return nil, wrapError(ctx, err, "Error querying order items:")
+	}
+	defer rows.Close()
+
+	var items []service.OrderItemSrv
+	for rows.Next() {
+		var item service.OrderItemSrv
+		err = rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.Price.Amount, &item.Price.Currency)
+		if err != nil {
+			return nil, wrapError(ctx, err, "Error scanning order item:")
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, err, "Error iterating order items:")
 	}
-	return err
+	return items, nil
 }
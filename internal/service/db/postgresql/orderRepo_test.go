@@ -0,0 +1,27 @@
+package postgresql
+
+import (
+	"tages-task-go/pkg/models/service"
+	"testing"
+)
+
+func TestRestoresStock(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: "pending", to: "cancelled", want: true},
+		{from: "paid", to: "cancelled", want: true},
+		{from: "paid", to: "refunded", want: true},
+		{from: "delivered", to: "refunded", want: false},
+		{from: "pending", to: "paid", want: false},
+		{from: "shipped", to: "delivered", want: false},
+	}
+	for _, tt := range tests {
+		transition := &service.OrderTransitionSrv{FromStatus: tt.from, ToStatus: tt.to}
+		if got := restoresStock(transition); got != tt.want {
+			t.Errorf("restoresStock(%s -> %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -2,11 +2,18 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"html"
+	"strings"
+	"tages-task-go/pkg/errs"
 	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/metrics"
 	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/tracing"
+	"time"
 )
 
 //type ProductRepository interface {
@@ -16,79 +23,246 @@ import (
 //}
 
 type productRepository struct {
-	db     *pgxpool.Pool
-	logger *logging.Logger
+	db *pgxpool.Pool
 }
 
-func NewProductRepository(db *pgxpool.Pool, logger *logging.Logger) *productRepository {
-	return &productRepository{db: db, logger: logger}
+func NewProductRepository(db *pgxpool.Pool) *productRepository {
+	return &productRepository{db: db}
 }
 
 // Создание нового продукта
-func (r *productRepository) CreateProduct(ctx context.Context, product service.ProductSrv) error {
-	query := `INSERT INTO products (name, price) VALUES ($1, $2)`
-	_, err := r.db.Exec(ctx, query, product.Name, product.Price)
+func (r *productRepository) CreateProduct(ctx context.Context, product service.ProductSrv) (service.ProductSrv, error) {
+	defer metrics.ObserveQuery("product", "CreateProduct", time.Now())
+	ctx, span := tracing.Start(ctx, "productRepository.CreateProduct")
+	defer span.End()
+
+	var created service.ProductSrv
+	query := `INSERT INTO products (name, price, currency, stock) VALUES ($1, $2, $3, $4)
+		RETURNING id, name, price, currency, stock`
+	err := r.db.QueryRow(ctx, query, product.Name, product.Price.Amount, product.Price.Currency, product.Stock).
+		Scan(&created.ID, &created.Name, &created.Price.Amount, &created.Price.Currency, &created.Stock)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-				pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			r.logger.Error(newErr)
-			return newErr
-		}
-		r.logger.Println("Error creating product:", err)
+		return created, wrapError(ctx, err, "Error creating product:")
 	}
-	return err
+	return created, nil
 }
 
 // Получение продукта по ID
 func (r *productRepository) GetProductByID(ctx context.Context, id int) (service.ProductSrv, error) {
+	defer metrics.ObserveQuery("product", "GetProductByID", time.Now())
+	ctx, span := tracing.Start(ctx, "productRepository.GetProductByID")
+	defer span.End()
+
 	var product service.ProductSrv
-	query := `SELECT id, name, price FROM products WHERE id = $1`
-	err := r.db.QueryRow(ctx, query, id).Scan(&product.ID, &product.Name, &product.Price)
+	query := `SELECT id, name, price, currency, stock FROM products WHERE id = $1`
+	err := r.db.QueryRow(ctx, query, id).Scan(&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.Stock)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return product, errs.ErrProductNotFound
+	}
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-				pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			r.logger.Error(newErr)
-			return product, newErr
-		}
-		r.logger.Println("Error fetching product by ID:", err)
+		return product, wrapError(ctx, err, "Error fetching product by ID:")
+	}
+	return product, nil
+}
+
+// Обновление названия и цены продукта. Остаток не меняется: его списывают заказы,
+// и запись прочитанного ранее значения затерла бы списание. Остаток меняет AdjustProductStock
+func (r *productRepository) UpdateProduct(ctx context.Context, product service.ProductSrv) (service.ProductSrv, error) {
+	defer metrics.ObserveQuery("product", "UpdateProduct", time.Now())
+	ctx, span := tracing.Start(ctx, "productRepository.UpdateProduct")
+	defer span.End()
+
+	var updated service.ProductSrv
+	query := `UPDATE products SET name = $1, price = $2, currency = $3 WHERE id = $4
+		RETURNING id, name, price, currency, stock`
+	err := r.db.QueryRow(ctx, query, product.Name, product.Price.Amount, product.Price.Currency, product.ID).
+		Scan(&updated.ID, &updated.Name, &updated.Price.Amount, &updated.Price.Currency, &updated.Stock)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return updated, errs.ErrProductNotFound
+	}
+	if err != nil {
+		return updated, wrapError(ctx, err, "Error updating product:")
+	}
+	return updated, nil
+}
+
+// AdjustProductStock атомарно меняет остаток продукта на delta. Остаток не может стать отрицательным:
+// в этом случае возвращается ошибка с errs.InsufficientStockError
+func (r *productRepository) AdjustProductStock(ctx context.Context, id, delta int) (service.ProductSrv, error) {
+	defer metrics.ObserveQuery("product", "AdjustProductStock", time.Now())
+	ctx, span := tracing.Start(ctx, "productRepository.AdjustProductStock")
+	defer span.End()
+
+	var updated service.ProductSrv
+	query := `UPDATE products SET stock = stock + $2 WHERE id = $1 AND stock + $2 >= 0
+		RETURNING id, name, price, currency, stock`
+	err := r.db.QueryRow(ctx, query, id, delta).
+		Scan(&updated.ID, &updated.Name, &updated.Price.Amount, &updated.Price.Currency, &updated.Stock)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return updated, r.adjustStockError(ctx, id, delta)
+	}
+	if err != nil {
+		return updated, wrapError(ctx, err, "Error adjusting product stock:")
+	}
+	return updated, nil
+}
+
+// adjustStockError выясняет, почему остаток не изменился: продукта нет или остатка не хватает для списания
+func (r *productRepository) adjustStockError(ctx context.Context, id, delta int) error {
+	var available int
+	err := r.db.QueryRow(ctx, "SELECT stock FROM products WHERE id = $1", id).Scan(&available)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errs.ErrProductNotFound
+	}
+	if err != nil {
+		return wrapError(ctx, err, "Error fetching product stock:")
+	}
+	logging.FromContext(ctx).Warnf("Insufficient stock for product %d: requested %d, available %d", id, -delta, available)
+	stockErr := &errs.InsufficientStockError{ProductID: id, Requested: -delta, Available: available}
+	return errs.New(errs.ErrConflict, stockErr.Error(), stockErr)
+}
+
+// Удаление продукта. Продукт, на который ссылаются позиции заказов, удалить нельзя:
+// в этом случае возвращается errs.ErrProductInUse
+func (r *productRepository) DeleteProduct(ctx context.Context, id int) error {
+	defer metrics.ObserveQuery("product", "DeleteProduct", time.Now())
+	ctx, span := tracing.Start(ctx, "productRepository.DeleteProduct")
+	defer span.End()
+
+	query := `DELETE FROM products WHERE id = $1`
+	tag, err := r.db.Exec(ctx, query, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		logging.FromContext(ctx).Warnf("Refusing to delete product %d referenced by orders", id)
+		return errs.ErrProductInUse
+	}
+	if err != nil {
+		return wrapError(ctx, err, "Error deleting product:")
+	}
+	if tag.RowsAffected() == 0 {
+		return errs.ErrProductNotFound
 	}
-	return product, err
+	return nil
 }
 
-// Получение всех продуктов
-func (r *productRepository) GetAllProducts(ctx context.Context) ([]service.ProductSrv, error) {
-	query := `SELECT id, name, price FROM products`
-	rows, err := r.db.Query(ctx, query)
+// ListProducts возвращает страницу продуктов по фильтру. Страницы выбираются по курсору
+// (keyset-пагинация), поэтому глубина листания не влияет на стоимость запроса
+func (r *productRepository) ListProducts(ctx context.Context, filter service.ProductFilterSrv) (service.ProductPageSrv, error) {
+	defer metrics.ObserveQuery("product", "ListProducts", time.Now())
+	ctx, span := tracing.Start(ctx, "productRepository.ListProducts")
+	defer span.End()
+
+	var page service.ProductPageSrv
+	sortBy, err := parseSort(filter.Sort, productSortColumns)
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok {
-			newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-				pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-			r.logger.Error(newErr)
-			return nil, newErr
+		return page, err
+	}
+
+	var q listQuery
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor, filter.Sort, sortBy.column)
+		if err != nil {
+			return page, err
 		}
-		r.logger.Println("Error querying products:", err)
-		return nil, err
+		q.after(sortBy, c)
+	}
+	if filter.MinPrice != nil {
+		q.where("price >= " + q.arg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		q.where("price <= " + q.arg(*filter.MaxPrice))
+	}
+	if filter.NameContains != "" {
+		q.where("name ILIKE " + q.arg(likePattern(filter.NameContains)))
+	}
+
+	// Выбираем на одну строку больше, чтобы узнать, есть ли следующая страница
+	query := q.build(`SELECT id, name, price, currency, stock FROM products`, sortBy, filter.Limit+1)
+	rows, err := r.db.Query(ctx, query, q.args...)
+	if err != nil {
+		return page, wrapError(ctx, err, "Error querying products:")
 	}
 	defer rows.Close()
 
-	var products []service.ProductSrv
 	for rows.Next() {
 		var product service.ProductSrv
-		err = rows.Scan(&product.ID, &product.Name, &product.Price)
+		err = rows.Scan(&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.Stock)
 		if err != nil {
-			if pgErr, ok := err.(*pgconn.PgError); ok {
-				newErr := fmt.Errorf("SQL Error: %s, Detail: %s, Where: %s, Code: %s, SQLState: %s",
-					pgErr.Message, pgErr.Detail, pgErr.Where, pgErr.Code, pgErr.SQLState())
-				r.logger.Error(newErr)
-				return nil, newErr
-			}
-			r.logger.Println("Error scanning product:", err)
-			return nil, err
+			return page, wrapError(ctx, err, "Error scanning product:")
 		}
-		products = append(products, product)
+		page.Items = append(page.Items, product)
 	}
+	if err = rows.Err(); err != nil {
+		return page, wrapError(ctx, err, "Error iterating products:")
+	}
+
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		last := page.Items[len(page.Items)-1]
+		next := cursor{Sort: filter.Sort, ID: last.ID}
+		switch sortBy.key {
+		case "name":
+			next.Value = last.Name
+		case "price":
+			next.Value = last.Price.Amount.String()
+		}
+		page.NextCursor = encodeCursor(next)
+	}
+	return page, nil
+}
+
+// Маркеры совпадений в выводе ts_headline. Из названия они предварительно удаляются,
+// поэтому после экранирования HTML их можно безопасно заменить тегами <mark></mark>
+const (
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+)
+
+// SearchProducts ищет продукты по названию. Полнотекстовое совпадение ищется по search_vector,
+// опечатки прощаются за счет триграммного сходства (pg_trgm). Результаты упорядочены по сумме
+// ts_rank и word_similarity. Highlight - название, экранированное для HTML, в котором совпавшие
+// слова обрамлены тегами <mark></mark>
+func (r *productRepository) SearchProducts(ctx context.Context, text string, limit int) ([]service.ProductSearchResultSrv, error) {
+	defer metrics.ObserveQuery("product", "SearchProducts", time.Now())
+	ctx, span := tracing.Start(ctx, "productRepository.SearchProducts")
+	defer span.End()
+
+	query := `WITH q AS (SELECT websearch_to_tsquery('simple', $1) AS tsq)
+		SELECT p.id, p.name, p.price, p.currency, p.stock,
+			ts_rank(p.search_vector, q.tsq) + word_similarity($1, p.name) AS rank,
+			ts_headline('simple', translate(p.name, $3, ''), q.tsq, $4) AS highlight
+		FROM products p, q
+		WHERE p.search_vector @@ q.tsq OR $1 <% p.name
+		ORDER BY rank DESC, p.id
+		LIMIT $2`
+	options := "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	rows, err := r.db.Query(ctx, query, text, limit, highlightStart+highlightStop, options)
+	if err != nil {
+		return nil, wrapError(ctx, err, "Error searching products:")
+	}
+	defer rows.Close()
+
+	var results []service.ProductSearchResultSrv
+	for rows.Next() {
+		var result service.ProductSearchResultSrv
+		product := &result.Product
+		err = rows.Scan(&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.Stock,
+			&result.Rank, &result.Highlight)
+		if err != nil {
+			return nil, wrapError(ctx, err, "Error scanning product search result:")
+		}
+		result.Highlight = highlightHTML(result.Highlight)
+		results = append(results, result)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, err, "Error iterating product search results:")
+	}
+	return results, nil
+}
 
-	return products, nil
+// highlightHTML экранирует вывод ts_headline для HTML и заменяет маркеры совпадений тегами <mark></mark>.
+// Без экранирования название вида <img onerror=...> стало бы хранимой XSS в интерфейсе, выводящем подсветку
+func highlightHTML(headline string) string {
+	escaped := html.EscapeString(headline)
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").Replace(escaped)
 }
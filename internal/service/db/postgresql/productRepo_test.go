@@ -0,0 +1,20 @@
+package postgresql
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{headline: "Green \x01tea\x02", want: "Green <mark>tea</mark>"},
+		{headline: "\x01<img\x02 src=x onerror=alert(1)>", want: "<mark>&lt;img</mark> src=x onerror=alert(1)&gt;"},
+		{headline: `Tom & Jerry's "mug"`, want: "Tom &amp; Jerry&#39;s &#34;mug&#34;"},
+		{headline: "<mark>fake</mark>", want: "&lt;mark&gt;fake&lt;/mark&gt;"},
+	}
+	for _, tt := range tests {
+		if got := highlightHTML(tt.headline); got != tt.want {
+			t.Errorf("highlightHTML(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
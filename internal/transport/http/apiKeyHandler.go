@@ -0,0 +1,74 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/models"
+	"tages-task-go/pkg/models/transport"
+)
+
+func (h *Handler) registerAPIKeyRoutes(router *mux.Router) {
+	// Ключами управляет только администратор, API-ключам эти маршруты недоступны
+	router.HandleFunc("/admin/api-keys", h.limited(rateLimitAdmin, authorize(h.issueAPIKey, "", auth.RoleAdmin))).Methods("POST")
+	router.HandleFunc("/admin/api-keys", h.limited(rateLimitAdmin, authorize(h.listAPIKeys, "", auth.RoleAdmin))).Methods("GET")
+	router.HandleFunc("/admin/api-keys/{id:[0-9]+}", h.limited(rateLimitAdmin, authorize(h.revokeAPIKey, "", auth.RoleAdmin))).Methods("DELETE")
+}
+
+// issueAPIKey - обработчик для выпуска нового API-ключа. Ключ целиком возвращается только в этом ответе
+func (h *Handler) issueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var keyDTO transport.APIKeyInputDTO
+	if err := decodeJSON(w, r, &keyDTO); err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	keyUC := models.FromDtoToUseCaseAPIKey(keyDTO)
+	principal, _ := auth.FromContext(r.Context())
+	keyUC.CreatedBy = principal.Subject
+
+	issued, err := h.storeUC.IssueAPIKey(r.Context(), keyUC)
+	if err != nil {
+		handleError(w, r, err, "Failed to issue api key")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/admin/api-keys/%d", issued.ID))
+	w.Header().Set("Cache-Control", "no-store")
+	sendJSONResponse(w, http.StatusCreated, models.FromUseCaseToDtoAPIKey(issued))
+}
+
+// listAPIKeys - обработчик для получения списка API-ключей без секретов
+func (h *Handler) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keysUC, err := h.storeUC.ListAPIKeys(r.Context())
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch api keys")
+		return
+	}
+
+	keysDTO := make([]transport.APIKeyDTO, 0, len(keysUC))
+	for _, keyUC := range keysUC {
+		keysDTO = append(keysDTO, models.FromUseCaseToDtoAPIKey(keyUC))
+	}
+
+	sendJSONResponse(w, http.StatusOK, keysDTO)
+}
+
+// revokeAPIKey - обработчик для отзыва API-ключа
+func (h *Handler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid api key ID")
+		return
+	}
+
+	if err := h.storeUC.RevokeAPIKey(r.Context(), id); err != nil {
+		handleError(w, r, err, "Failed to revoke api key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
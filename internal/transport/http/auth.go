@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/models/usecase"
+)
+
+// apiKeyHeader - заголовок с API-ключом межсервисного клиента
+const apiKeyHeader = "X-API-Key"
+
+type APIKeyUseCase interface {
+	IssueAPIKey(ctx context.Context, key usecase.APIKeyUC) (usecase.APIKeyUC, error)
+	ListAPIKeys(ctx context.Context) ([]usecase.APIKeyUC, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (usecase.APIKeyUC, error)
+}
+
+// TokenVerifier проверяет токен доступа и возвращает субъекта, которому он выдан
+type TokenVerifier interface {
+	Verify(token string) (auth.Principal, error)
+}
+
+var (
+	// errMissingToken возвращается для запроса без заголовка Authorization: Bearer
+	errMissingToken = errs.New(errs.ErrUnauthorized, "bearer access token is required", nil)
+	// errAccessDenied возвращается, если у субъекта нет роли или права, необходимых для маршрута
+	errAccessDenied = errs.New(errs.ErrForbidden, "insufficient role or scope for this operation", nil)
+)
+
+// authenticate проверяет API-ключ из заголовка X-API-Key или токен доступа из заголовка Authorization
+// и кладет субъекта запроса в контекст
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rawKey := r.Header.Get(apiKeyHeader); rawKey != "" {
+			key, err := h.storeUC.AuthenticateAPIKey(r.Context(), rawKey)
+			if err != nil {
+				handleError(w, r, err, "Authentication failed")
+				return
+			}
+			principal := auth.Principal{Subject: "api-key:" + key.Prefix, APIKeyID: key.ID, Scopes: key.Scopes}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+			return
+		}
+
+		scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			handleError(w, r, errMissingToken, "Authentication required")
+			return
+		}
+
+		principal, err := h.verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			handleError(w, r, errs.New(errs.ErrUnauthorized, "access token is invalid or expired", err),
+				"Authentication failed")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	})
+}
+
+// routeRolesKey - ключ контекста с ролями, которым открыт маршрут
+type routeRolesKey struct{}
+
+// authorize пропускает к обработчику пользователей с одной из ролей и API-ключи с правом scope.
+// Администратору доступны все маршруты, пустой scope закрывает маршрут для API-ключей.
+// Роли маршрута сохраняются в контексте: по ним ownCustomerID определяет, допущен ли субъект только как покупатель
+func authorize(next http.HandlerFunc, scope string, roles ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok || !principal.Allows(scope, roles...) {
+			handleError(w, r, errAccessDenied, "Access denied")
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), routeRolesKey{}, roles)))
+	}
+}
+
+// ownCustomerID возвращает ID покупателя, если к маршруту субъект допущен только как покупатель
+// и ему доступны только его собственные данные
+func ownCustomerID(r *http.Request) (int, bool) {
+	principal, _ := auth.FromContext(r.Context())
+	roles, _ := r.Context().Value(routeRolesKey{}).([]string)
+	return principal.OwnCustomerID(roles...)
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/models/transport"
+	"testing"
+)
+
+func TestAuthorizeRoleMatrix(t *testing.T) {
+	router := newOrderRouter(newMemoryOrderRepo(
+		service.OrderSrv{ID: 1, CustomerID: 1, Status: "pending"},
+		service.OrderSrv{ID: 2, CustomerID: 2, Status: "pending"},
+	))
+	tests := []struct {
+		token      string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{token: "", method: http.MethodGet, path: "/orders", wantStatus: http.StatusUnauthorized},
+		{token: "forged", method: http.MethodGet, path: "/orders", wantStatus: http.StatusUnauthorized},
+		{token: "admin", method: http.MethodGet, path: "/orders", wantStatus: http.StatusOK},
+		{token: "ops", method: http.MethodGet, path: "/orders", wantStatus: http.StatusOK},
+		{token: "support", method: http.MethodGet, path: "/orders/2", wantStatus: http.StatusOK},
+		{token: "catalog", method: http.MethodGet, path: "/orders", wantStatus: http.StatusForbidden},
+		{token: "customer-1", method: http.MethodGet, path: "/orders/1", wantStatus: http.StatusOK},
+		{token: "customer-1", method: http.MethodGet, path: "/orders/2", wantStatus: http.StatusNotFound},
+		{token: "customer-1", method: http.MethodGet, path: "/orders/2/transitions", wantStatus: http.StatusNotFound},
+		{token: "customer-1", method: http.MethodPost, path: "/orders/1/transitions", body: `{"to":"cancelled"}`, wantStatus: http.StatusForbidden},
+		{token: "support", method: http.MethodPost, path: "/orders/1/transitions", body: `{"to":"cancelled"}`, wantStatus: http.StatusForbidden},
+		{token: "catalog", method: http.MethodPost, path: "/orders/1/transitions", body: `{"to":"cancelled"}`, wantStatus: http.StatusForbidden},
+		{token: "ops", method: http.MethodPost, path: "/orders/1/transitions", body: `{"to":"paid"}`, wantStatus: http.StatusCreated},
+		{token: "admin", method: http.MethodPost, path: "/orders/2/transitions", body: `{"to":"paid"}`, wantStatus: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token+" "+tt.method+" "+tt.path, func(t *testing.T) {
+			if w := serve(router, tt.token, tt.method, tt.path, tt.body); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestCustomerScopeFollowsGrantingRole(t *testing.T) {
+	router := newOrderRouter(newMemoryOrderRepo(
+		service.OrderSrv{ID: 1, CustomerID: 1, Status: "pending"},
+		service.OrderSrv{ID: 2, CustomerID: 2, Status: "pending"},
+	))
+	tests := []struct {
+		name    string
+		token   string
+		wantIDs []int
+	}{
+		// catalog_admin не дает доступа к заказам: к ним субъект допущен как покупатель и видит только свои
+		{name: "catalog admin and customer", token: "catalog-buyer", wantIDs: []int{1}},
+		{name: "support and customer", token: "support-buyer", wantIDs: []int{1, 2}},
+		{name: "admin and customer", token: "admin-customer", wantIDs: []int{1, 2}},
+		{name: "customer", token: "customer-1", wantIDs: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.token, http.MethodGet, "/orders", "")
+			var page transport.OrderPageDTO
+			if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+				t.Fatalf("decode page: %v", err)
+			}
+			var ids []int
+			for _, order := range page.Items {
+				ids = append(ids, order.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("orders = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("orders = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+
+	if w := serve(router, "catalog-buyer", http.MethodGet, "/orders/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("catalog admin and customer reading another customer's order = %d, want 404", w.Code)
+	}
+	w := serve(router, "catalog-buyer", http.MethodPost, "/orders", `{"customerId":2,"items":[{"productId":1,"quantity":1}]}`)
+	var created transport.OrderDTO
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create order = %d: %s", w.Code, w.Body)
+	}
+	if created.CustomerID != 1 {
+		t.Errorf("order created for customer %d, want the token's customer 1", created.CustomerID)
+	}
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/andybalholm/brotli"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// minCompressSize - ответы меньше этого размера отправляются без сжатия: выигрыш не окупает затрат
+const minCompressSize = 1024
+
+// supportedEncodings - поддерживаемые кодировки в порядке предпочтения при равном весе q
+var supportedEncodings = []string{"br", "gzip", "deflate"}
+
+// compress сжимает ответ кодировкой, выбранной по заголовку Accept-Encoding
+func (h *Handler) compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding выбирает кодировку с наибольшим весом q. Пустая строка - ответ не сжимается
+func negotiateEncoding(header string) string {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supportedEncodings {
+		q, ok := weights[encoding]
+		if !ok {
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter накапливает начало ответа и включает сжатие, только если ответ не меньше minCompressSize
+// и обработчик не сжал его сам
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+	buf      []byte
+	started  bool
+	encoder  io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.started {
+		return
+	}
+	// Информационные ответы 1xx отправляются сразу и не завершают ответ
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if !cw.started {
+		cw.buf = append(cw.buf, data...)
+		if len(cw.buf) < minCompressSize {
+			return len(data), nil
+		}
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(data)
+	}
+	return cw.ResponseWriter.Write(data)
+}
+
+// start отправляет заголовки и накопленное начало ответа, сжатое или как есть
+func (cw *compressWriter) start(large bool) error {
+	cw.started = true
+	header := cw.Header()
+	if large && header.Get("Content-Encoding") == "" && bodyAllowed(cw.status) {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		cw.encoder = newEncoder(cw.encoding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush отправляет накопленное начало ответа и все сжатые к этому моменту данные. Ответ, который
+// обработчик сбрасывает по частям, считается потоковым и сжимается сразу, независимо от размера
+func (cw *compressWriter) Flush() {
+	if !cw.started {
+		if err := cw.start(true); err != nil {
+			return
+		}
+	}
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return
+		}
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap возвращает исходный ResponseWriter для http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close завершает ответ: короткий ответ отправляется без сжатия, у сжатого дописывается хвост кодировки
+func (cw *compressWriter) Close() error {
+	if !cw.started {
+		return cw.start(false)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	switch encoding {
+	case "br":
+		return brotli.NewWriterLevel(w, brotli.DefaultCompression)
+	case "gzip":
+		return gzip.NewWriter(w)
+	default:
+		// В HTTP кодировка deflate означает поток zlib (RFC 9110)
+		return zlib.NewWriter(w)
+	}
+}
+
+// bodyAllowed проверяет, может ли ответ с этим статусом содержать тело
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFlushThroughMiddlewareChain(t *testing.T) {
+	h := &Handler{}
+	recorder := httptest.NewRecorder()
+	var flushedBody string
+	handler := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: first\n\n")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Fatalf("Flush error: %v", err)
+		}
+		flushedBody = readGzipPrefix(t, recorder.Body.String())
+		io.WriteString(w, "data: second\n\n")
+	}), h.accessLog, h.instrument, h.compress, h.recoverPanic)
+
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(recorder, r)
+
+	if !recorder.Flushed {
+		t.Error("Flush did not reach the connection")
+	}
+	if flushedBody != "data: first\n\n" {
+		t.Errorf("flushed body = %q, want the first event", flushedBody)
+	}
+	if got := recorder.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "data: first\n\ndata: second\n\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+// readGzipPrefix разжимает уже сброшенную часть незавершенного gzip-потока
+func readGzipPrefix(t *testing.T, data string) string {
+	t.Helper()
+	reader, err := gzip.NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("flushed data is not a gzip stream: %v", err)
+	}
+	// Поток еще не закрыт, поэтому после сброшенных данных чтение заканчивается ошибкой unexpected EOF
+	prefix, _ := io.ReadAll(reader)
+	return string(prefix)
+}
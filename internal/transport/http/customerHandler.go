@@ -0,0 +1,229 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/models"
+	"tages-task-go/pkg/models/transport"
+	"tages-task-go/pkg/models/usecase"
+)
+
+type CustomerUseCase interface {
+	CreateCustomer(ctx context.Context, customer usecase.CustomerUC) (usecase.CustomerUC, error)
+	GetCustomer(ctx context.Context, id int) (usecase.CustomerUC, error)
+	ListCustomers(ctx context.Context, filter usecase.CustomerFilterUC) (usecase.CustomerPageUC, error)
+	UpdateCustomer(ctx context.Context, customer usecase.CustomerUC) (usecase.CustomerUC, error)
+	DeleteCustomer(ctx context.Context, id int) error
+	ListCustomerOrders(ctx context.Context, customerID int, filter usecase.OrderFilterUC) (usecase.OrderPageUC, error)
+}
+
+func (h *Handler) registerCustomerRoutes(router *mux.Router) {
+	// Покупателями управляет support, покупатель видит только свою карточку и свои заказы.
+	// API-ключам данные покупателей недоступны
+	readers := []string{auth.RoleOps, auth.RoleSupport, auth.RoleCustomer}
+	router.HandleFunc("/customers", h.limited(rateLimitCustomers, authorize(h.createCustomer, "", auth.RoleSupport))).Methods("POST")
+	router.HandleFunc("/customers", h.limited(rateLimitCustomers, authorize(h.listCustomers, "", auth.RoleOps, auth.RoleSupport))).Methods("GET")
+	router.HandleFunc("/customers/{id:[0-9]+}", h.limited(rateLimitCustomers, authorize(h.getCustomerByID, "", readers...))).Methods("GET")
+	router.HandleFunc("/customers/{id:[0-9]+}", h.limited(rateLimitCustomers, authorize(h.updateCustomer, "", auth.RoleSupport))).Methods("PUT")
+	router.HandleFunc("/customers/{id:[0-9]+}", h.limited(rateLimitCustomers, authorize(h.patchCustomer, "", auth.RoleSupport))).Methods("PATCH")
+	router.HandleFunc("/customers/{id:[0-9]+}", h.limited(rateLimitCustomers, authorize(h.deleteCustomer, "", auth.RoleAdmin))).Methods("DELETE")
+	router.HandleFunc("/customers/{id:[0-9]+}/orders", h.limited(rateLimitCustomers, authorize(h.listCustomerOrders, "", readers...))).Methods("GET")
+}
+
+// customerRequiredFields - поля покупателя, которые нельзя пропустить или сбросить в null
+var customerRequiredFields = []string{"name", "email"}
+
+// createCustomer - обработчик для создания нового покупателя
+func (h *Handler) createCustomer(w http.ResponseWriter, r *http.Request) {
+	var customerDTO transport.CustomerInputDTO
+	if err := decodeJSON(w, r, &customerDTO, customerRequiredFields...); err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	created, err := h.storeUC.CreateCustomer(r.Context(), models.FromDtoToUseCaseCustomer(customerDTO))
+	if err != nil {
+		handleError(w, r, err, "Failed to create customer")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/customers/%d", created.ID))
+	sendJSONResponse(w, http.StatusCreated, models.FromUseCaseToDtoCustomer(created))
+}
+
+// listCustomers - обработчик для получения страницы покупателей.
+// Поддерживает фильтры name, email, сортировку sort и пагинацию limit/cursor
+func (h *Handler) listCustomers(w http.ResponseWriter, r *http.Request) {
+	query := newQueryParams(r)
+	filterDTO := transport.CustomerFilterDTO{
+		NameContains: query.string("name"),
+		Email:        query.string("email"),
+		Sort:         query.string("sort"),
+		Limit:        query.int("limit"),
+		Cursor:       query.string("cursor"),
+	}
+	if err := query.err(); err != nil {
+		handleError(w, r, err, "Invalid query parameters")
+		return
+	}
+	if err := validateStruct(&filterDTO); err != nil {
+		handleError(w, r, err, "Invalid query parameters")
+		return
+	}
+
+	pageUC, err := h.storeUC.ListCustomers(r.Context(), models.FromDtoToUseCaseCustomerFilter(filterDTO))
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch customers")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoCustomerPage(pageUC))
+}
+
+// getCustomerByID - обработчик для получения покупателя по ID
+func (h *Handler) getCustomerByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid customer ID")
+		return
+	}
+	if customerID, own := ownCustomerID(r); own && id != customerID {
+		handleError(w, r, errs.ErrCustomerNotFound, "Failed to fetch customer")
+		return
+	}
+
+	customerUC, err := h.storeUC.GetCustomer(r.Context(), id)
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch customer")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoCustomer(customerUC))
+}
+
+// updateCustomer - обработчик для полной замены данных покупателя
+func (h *Handler) updateCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid customer ID")
+		return
+	}
+
+	var customerDTO transport.CustomerInputDTO
+	if err := decodeJSON(w, r, &customerDTO, customerRequiredFields...); err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	customerUC := models.FromDtoToUseCaseCustomer(customerDTO)
+	customerUC.ID = id
+	h.saveCustomer(w, r, customerUC)
+}
+
+// patchCustomer - обработчик для частичного обновления покупателя в семантике JSON Merge Patch (RFC 7396)
+func (h *Handler) patchCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid customer ID")
+		return
+	}
+
+	patch, err := readBody(w, r)
+	if err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	customerUC, err := h.storeUC.GetCustomer(r.Context(), id)
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch customer")
+		return
+	}
+
+	current, err := json.Marshal(models.FromUseCaseToDtoCustomerInput(customerUC))
+	if err != nil {
+		handleError(w, r, err, "Failed to encode customer")
+		return
+	}
+	patched, err := applyMergePatch(current, patch)
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid merge patch")
+		return
+	}
+
+	var customerDTO transport.CustomerInputDTO
+	if err := unmarshalAndValidate(patched, &customerDTO, customerRequiredFields...); err != nil {
+		handleError(w, r, err, "Invalid merge patch")
+		return
+	}
+
+	patchedUC := models.FromDtoToUseCaseCustomer(customerDTO)
+	patchedUC.ID = id
+	h.saveCustomer(w, r, patchedUC)
+}
+
+// saveCustomer сохраняет новое состояние покупателя и возвращает его клиенту
+func (h *Handler) saveCustomer(w http.ResponseWriter, r *http.Request, customer usecase.CustomerUC) {
+	customerUC, err := h.storeUC.UpdateCustomer(r.Context(), customer)
+	if err != nil {
+		handleError(w, r, err, "Failed to update customer")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoCustomer(customerUC))
+}
+
+// deleteCustomer - обработчик для удаления покупателя
+func (h *Handler) deleteCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid customer ID")
+		return
+	}
+
+	if err := h.storeUC.DeleteCustomer(r.Context(), id); err != nil {
+		handleError(w, r, err, "Failed to delete customer")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listCustomerOrders - обработчик для получения истории заказов покупателя.
+// Поддерживает те же фильтры, сортировку и пагинацию, что и GET /orders
+func (h *Handler) listCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid customer ID")
+		return
+	}
+	if customerID, own := ownCustomerID(r); own && id != customerID {
+		handleError(w, r, errs.ErrCustomerNotFound, "Failed to fetch customer orders")
+		return
+	}
+
+	filterDTO, err := parseOrderFilter(r)
+	if err != nil {
+		handleError(w, r, err, "Invalid query parameters")
+		return
+	}
+
+	pageUC, err := h.storeUC.ListCustomerOrders(r.Context(), id, models.FromDtoToUseCaseOrderFilter(filterDTO))
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch customer orders")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoOrderPage(pageUC))
+}
@@ -2,34 +2,58 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/metrics"
+	"tages-task-go/pkg/ratelimit"
 )
 
 type StoreUseCase interface {
 	OrderUseCase
 	ProductUseCase
+	CustomerUseCase
+	IdempotencyUseCase
+	APIKeyUseCase
+	HealthUseCase
 }
 
 type storeUseCase struct {
 	OrderUseCase
 	ProductUseCase
+	CustomerUseCase
+	IdempotencyUseCase
+	APIKeyUseCase
+	HealthUseCase
 }
 
-func NewStoreUseCase(orderUC OrderUseCase, productUC ProductUseCase) StoreUseCase {
+func NewStoreUseCase(orderUC OrderUseCase, productUC ProductUseCase, customerUC CustomerUseCase,
+	idempotencyUC IdempotencyUseCase, apiKeyUC APIKeyUseCase, healthUC HealthUseCase) StoreUseCase {
 	return &storeUseCase{
-		OrderUseCase:   orderUC,
-		ProductUseCase: productUC,
+		OrderUseCase:       orderUC,
+		ProductUseCase:     productUC,
+		CustomerUseCase:    customerUC,
+		IdempotencyUseCase: idempotencyUC,
+		APIKeyUseCase:      apiKeyUC,
+		HealthUseCase:      healthUC,
 	}
 }
 
 type Handler struct {
-	storeUC StoreUseCase
+	storeUC  StoreUseCase
+	verifier TokenVerifier
+	limiter  *ratelimit.Limiter
 }
 
-func NewHandler(storeUC StoreUseCase) *Handler {
+// NewHandler создает обработчики маршрутов. limiter может быть nil, тогда частота запросов не ограничивается
+func NewHandler(storeUC StoreUseCase, verifier TokenVerifier, limiter *ratelimit.Limiter) *Handler {
 	return &Handler{
-		storeUC: storeUC,
+		storeUC:  storeUC,
+		verifier: verifier,
+		limiter:  limiter,
 	}
 }
 
@@ -37,11 +61,41 @@ func NewHandler(storeUC StoreUseCase) *Handler {
 func (h *Handler) InitRoutes() *mux.Router {
 	router := mux.NewRouter()
 
+	// Общая цепочка middleware для всех запросов. Порядок важен: идентификатор запроса нужен журналу,
+	// журнал и метрики видят итоговый статус после перехвата паники и размер уже сжатого ответа
+	middlewares := []middleware{h.trace, h.withRequestID, h.accessLog, h.instrument, h.compress, h.recoverPanic}
+	router.Use(func(next http.Handler) http.Handler {
+		return chain(next, middlewares...)
+	})
+
+	// Middleware роутера не вызываются для несуществующих маршрутов, поэтому цепочка
+	// для ответов 404 и 405 подключается отдельно
+	router.NotFoundHandler = chain(http.HandlerFunc(notFoundHandler), middlewares...)
+	router.MethodNotAllowedHandler = chain(http.HandlerFunc(methodNotAllowedHandler), middlewares...)
+
+	// Метрики отдаются без аутентификации, доступ к ним ограничивается на уровне сети
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Подключаем проверки живости и готовности
+	h.registerHealthRoutes(router)
+
+	// Все маршруты API требуют токен доступа или API-ключ, роли и права проверяются на каждом маршруте.
+	// Лимит по IP-адресу стоит перед аутентификацией, чтобы ограничивать и запросы с неверными учетными данными
+	api := router.PathPrefix("/").Subrouter()
+	api.Use(h.limitedByIP, h.authenticate)
+
 	// Подключаем маршруты для Order
-	h.registerOrderRoutes(router)
+	h.registerOrderRoutes(api)
 
 	// Подключаем маршруты для Product
-	h.registerProductRoutes(router)
+	h.registerProductRoutes(api)
+
+	// Подключаем маршруты для Customer
+	h.registerCustomerRoutes(api)
+
+	// Подключаем административные маршруты
+	h.registerAPIKeyRoutes(api)
+	h.registerLogLevelRoutes(api)
 
 	return router
 }
@@ -53,9 +107,59 @@ func sendJSONResponse(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// handleError обрабатывает ошибки и отправляет соответствующий HTTP-ответ
-func handleError(w http.ResponseWriter, err error, msg string, status int) {
-	if err != nil {
-		http.Error(w, msg, status)
+// handleError обрабатывает ошибки и отправляет соответствующий HTTP-ответ в формате problem+json.
+// Статус выбирается по категории ошибки, для клиентских ошибок к msg добавляется
+// описание из доменной ошибки, внутренние детали клиенту не отправляются
+func handleError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if err == nil {
+		return
+	}
+
+	// Ошибка попадает в спан запроса, статус спана по коду ответа выставляет middleware trace
+	trace.SpanFromContext(r.Context()).RecordError(err)
+
+	status := errorStatus(err)
+	problem := newProblem(r, status, msg)
+	if status < http.StatusInternalServerError {
+		var validationErr *ValidationError
+		var domainErr *errs.Error
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			problem.Detail = fmt.Sprintf("%s: request body exceeds %d bytes", msg, maxBytesErr.Limit)
+		case errors.As(err, &validationErr):
+			problem.Detail = msg + ": " + validationErr.Message
+			problem.Errors = validationErr.Fields
+		case errors.As(err, &domainErr):
+			problem.Detail = msg + ": " + domainErr.Message
+		}
+	}
+	writeProblem(w, r, problem)
+}
+
+// errorStatus сопоставляет категорию ошибки HTTP-статусу
+func errorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errs.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrUnprocessable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, errs.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, errs.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
 	}
 }
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"net/http"
+	"tages-task-go/pkg/models"
+	"tages-task-go/pkg/models/transport"
+	"tages-task-go/pkg/models/usecase"
+)
+
+type HealthUseCase interface {
+	Readiness(ctx context.Context) usecase.HealthReportUC
+}
+
+// registerHealthRoutes подключает проверки для оркестратора. Они не требуют аутентификации
+// и не ограничиваются по частоте, иначе оркестратор не сможет опрашивать сервис
+func (h *Handler) registerHealthRoutes(router *mux.Router) {
+	router.HandleFunc("/healthz", h.liveness).Methods("GET")
+	router.HandleFunc("/readyz", h.readiness).Methods("GET")
+}
+
+// liveness сообщает, что процесс жив и обрабатывает запросы. Внешние зависимости не проверяются,
+// чтобы недоступность базы не приводила к перезапуску сервиса
+func (h *Handler) liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	sendJSONResponse(w, http.StatusOK, transport.HealthReportDTO{Status: string(usecase.HealthStatusUp)})
+}
+
+// readiness сообщает, готов ли сервис принимать запросы, с результатом проверки каждого компонента.
+// Если хотя бы один компонент не готов, отвечает 503
+func (h *Handler) readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.storeUC.Readiness(r.Context())
+	status := http.StatusOK
+	if report.Status != usecase.HealthStatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	sendJSONResponse(w, status, models.FromUseCaseToDtoHealthReport(report))
+}
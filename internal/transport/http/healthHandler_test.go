@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"tages-task-go/internal/usecase"
+	"tages-task-go/pkg/models/transport"
+	"testing"
+)
+
+// fakeHealthRepo отвечает на проверки готовности заданными результатами
+type fakeHealthRepo struct {
+	pingErr error
+	version uint
+}
+
+func (f fakeHealthRepo) Ping(context.Context) error {
+	return f.pingErr
+}
+
+func (f fakeHealthRepo) MigrationVersion(context.Context) (uint, bool, error) {
+	return f.version, false, nil
+}
+
+func (f fakeHealthRepo) LatestMigrationVersion() (uint, error) {
+	return 4, nil
+}
+
+func TestReadinessHidesFailureDetails(t *testing.T) {
+	repo := fakeHealthRepo{pingErr: errors.New(`dial tcp 10.0.0.5:5432: password authentication failed for user "shop"`), version: 3}
+	healthUC := usecase.NewHealthUseCase(repo)
+	router := NewHandler(NewStoreUseCase(nil, nil, nil, nil, nil, healthUC), nil, nil).InitRoutes()
+
+	w := serve(router, "", http.MethodGet, "/readyz", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	for _, leak := range []string{"10.0.0.5", "shop", "schema version"} {
+		if strings.Contains(w.Body.String(), leak) {
+			t.Errorf("response %s contains %q", w.Body, leak)
+		}
+	}
+
+	var report transport.HealthReportDTO
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Components["database"].Status != "down" || report.Components["migrations"].Status != "down" ||
+		report.Components["shutdown"].Status != "up" {
+		t.Errorf("components = %+v, want database and migrations down", report.Components)
+	}
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/models/usecase"
+)
+
+// idempotencyKeyHeader - заголовок с ключом идемпотентности запроса
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength - максимальная длина ключа идемпотентности
+const maxIdempotencyKeyLength = 255
+
+// replayedResponseHeaders - заголовки ответа, которые сохраняются вместе с телом и возвращаются на повторы
+var replayedResponseHeaders = []string{"Content-Type", "Location"}
+
+type IdempotencyUseCase interface {
+	BeginIdempotentRequest(ctx context.Context, request usecase.IdempotentRequestUC) (*usecase.IdempotentResponseUC, error)
+	CompleteIdempotentRequest(ctx context.Context, request usecase.IdempotentRequestUC, response usecase.IdempotentResponseUC) error
+	ReleaseIdempotentRequest(ctx context.Context, request usecase.IdempotentRequestUC) error
+}
+
+// responseRecorder передает ответ клиенту и одновременно запоминает статус и тело
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+// idempotent выполняет обработчик не более одного раза для каждого значения заголовка Idempotency-Key.
+// Повтор с тем же ключом и телом получает сохраненный ответ, повтор с другим телом - 422.
+// Ответы 5xx не сохраняются, и запрос с тем же ключом можно повторить
+func (h *Handler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			handleError(w, r, &ValidationError{
+				Message: "request headers contain invalid values",
+				Fields:  []FieldError{{Field: idempotencyKeyHeader, Message: "must be at most 255 characters long"}},
+			}, "Invalid idempotency key")
+			return
+		}
+
+		body, err := readBody(w, r)
+		if err != nil {
+			handleError(w, r, err, "Invalid request payload")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Ключи разных субъектов не пересекаются, даже если клиенты выбрали одинаковые значения
+		principal, _ := auth.FromContext(r.Context())
+		hash := sha256.Sum256(body)
+		request := usecase.IdempotentRequestUC{
+			Scope:       r.Method + " " + r.URL.Path + " " + principal.Subject,
+			Key:         key,
+			RequestHash: hex.EncodeToString(hash[:]),
+		}
+		replay, err := h.storeUC.BeginIdempotentRequest(r.Context(), request)
+		if err != nil {
+			handleError(w, r, err, "Failed to process idempotency key")
+			return
+		}
+		if replay != nil {
+			writeReplay(w, r, *replay)
+			return
+		}
+
+		// Ключ освобождается, если обработчик не дошел до сохранения ответа, в том числе при панике.
+		// Контекст без отмены нужен, чтобы ключ сохранился и освободился даже после отключения клиента
+		ctx := context.WithoutCancel(r.Context())
+		saved := false
+		defer func() {
+			if !saved {
+				h.storeUC.ReleaseIdempotentRequest(ctx, request)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		response := usecase.IdempotentResponseUC{
+			StatusCode: rec.status,
+			Headers:    make(map[string]string, len(replayedResponseHeaders)),
+			Body:       rec.body.Bytes(),
+		}
+		for _, name := range replayedResponseHeaders {
+			if value := w.Header().Get(name); value != "" {
+				response.Headers[name] = value
+			}
+		}
+		// Если ответ не удалось сохранить, ключ освобождается: иначе повтор до истечения срока получал бы 409,
+		// хотя запрос уже выполнен. Повтор после освобождения выполнит запрос заново, поэтому ошибка логируется
+		if err := h.storeUC.CompleteIdempotentRequest(ctx, request, response); err != nil {
+			logging.FromContext(ctx).Errorf("Failed to save response for idempotency key %q, releasing the key: %v", key, err)
+			return
+		}
+		saved = true
+	}
+}
+
+// writeReplay отправляет сохраненный ответ на повтор идемпотентного запроса. В описании ошибки
+// requestId заменяется идентификатором повтора, чтобы по нему находились записи журнала этого запроса
+func writeReplay(w http.ResponseWriter, r *http.Request, response usecase.IdempotentResponseUC) {
+	body := response.Body
+	if response.Headers["Content-Type"] == problemContentType {
+		var problem Problem
+		if err := json.Unmarshal(body, &problem); err == nil {
+			problem.RequestID = requestIDFromContext(r.Context())
+			if encoded, err := json.Marshal(problem); err == nil {
+				body = append(encoded, '\n')
+			}
+		}
+	}
+
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(response.StatusCode)
+	w.Write(body)
+}
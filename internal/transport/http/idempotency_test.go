@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"tages-task-go/internal/usecase"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/models/service"
+	"testing"
+	"time"
+)
+
+// memoryIdempotencyRepo хранит ключи идемпотентности в памяти с той же семантикой, что и репозиторий
+type memoryIdempotencyRepo struct {
+	keys        map[string]service.IdempotencyKeySrv
+	completeErr error
+}
+
+func newMemoryIdempotencyRepo() *memoryIdempotencyRepo {
+	return &memoryIdempotencyRepo{keys: make(map[string]service.IdempotencyKeySrv)}
+}
+
+func (m *memoryIdempotencyRepo) ReserveIdempotencyKey(_ context.Context, key service.IdempotencyKeySrv) (service.IdempotencyKeySrv, bool, error) {
+	if existing, ok := m.keys[key.Scope+" "+key.Key]; ok {
+		return existing, false, nil
+	}
+	m.keys[key.Scope+" "+key.Key] = key
+	return key, true, nil
+}
+
+func (m *memoryIdempotencyRepo) CompleteIdempotencyKey(_ context.Context, key service.IdempotencyKeySrv) error {
+	if m.completeErr != nil {
+		return m.completeErr
+	}
+	if existing, ok := m.keys[key.Scope+" "+key.Key]; ok && existing.StatusCode == 0 {
+		key.ExpiresAt = existing.ExpiresAt
+		m.keys[key.Scope+" "+key.Key] = key
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyRepo) DeleteIdempotencyKey(_ context.Context, scope, key string) error {
+	if existing, ok := m.keys[scope+" "+key]; ok && existing.StatusCode == 0 {
+		delete(m.keys, scope+" "+key)
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyRepo) DeleteExpiredIdempotencyKeys(context.Context) (int64, error) {
+	return 0, nil
+}
+
+// countingHandler отвечает заданными статусами по очереди и считает вызовы
+type countingHandler struct {
+	statuses []int
+	calls    int
+}
+
+func (c *countingHandler) serve(w http.ResponseWriter, _ *http.Request) {
+	status := c.statuses[min(c.calls, len(c.statuses)-1)]
+	c.calls++
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/orders/1")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"id":1}`))
+}
+
+func newIdempotentHandler(repo *memoryIdempotencyRepo, next *countingHandler) http.HandlerFunc {
+	h := &Handler{storeUC: NewStoreUseCase(nil, nil, nil, usecase.NewIdempotencyUseCase(repo, time.Hour), nil, nil)}
+	return h.idempotent(next.serve)
+}
+
+func sendIdempotent(handler http.HandlerFunc, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(idempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	next := &countingHandler{statuses: []int{http.StatusCreated}}
+	handler := newIdempotentHandler(newMemoryIdempotencyRepo(), next)
+
+	first := sendIdempotent(handler, "key-1", `{"items":[]}`)
+	if first.Code != http.StatusCreated || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first request = %d, replayed %q", first.Code, first.Header().Get("Idempotent-Replayed"))
+	}
+
+	replay := sendIdempotent(handler, "key-1", `{"items":[]}`)
+	if next.calls != 1 {
+		t.Errorf("handler called %d times, want 1", next.calls)
+	}
+	if replay.Code != http.StatusCreated || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replay = %d, replayed %q, want 201 replayed", replay.Code, replay.Header().Get("Idempotent-Replayed"))
+	}
+	if replay.Body.String() != first.Body.String() || replay.Header().Get("Location") != "/orders/1" {
+		t.Errorf("replay body %q, location %q, want the saved response", replay.Body, replay.Header().Get("Location"))
+	}
+
+	if reused := sendIdempotent(handler, "key-1", `{"items":[{}]}`); reused.Code != http.StatusUnprocessableEntity {
+		t.Errorf("same key with another body = %d, want 422", reused.Code)
+	}
+	if other := sendIdempotent(handler, "key-2", `{"items":[]}`); other.Code != http.StatusCreated || next.calls != 2 {
+		t.Errorf("another key = %d after %d calls, want a new execution", other.Code, next.calls)
+	}
+	sendIdempotent(handler, "", `{"items":[]}`)
+	sendIdempotent(handler, "", `{"items":[]}`)
+	if next.calls != 4 {
+		t.Errorf("handler called %d times, want requests without a key to run every time", next.calls)
+	}
+}
+
+func TestIdempotentRetriesServerErrors(t *testing.T) {
+	next := &countingHandler{statuses: []int{http.StatusServiceUnavailable, http.StatusCreated}}
+	handler := newIdempotentHandler(newMemoryIdempotencyRepo(), next)
+
+	if first := sendIdempotent(handler, "key", `{}`); first.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first request = %d, want 503", first.Code)
+	}
+	if retry := sendIdempotent(handler, "key", `{}`); retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after 5xx = %d, replayed %q, want a new execution", retry.Code, retry.Header().Get("Idempotent-Replayed"))
+	}
+	if next.calls != 2 {
+		t.Errorf("handler called %d times, want 2", next.calls)
+	}
+}
+
+func TestIdempotentReleasesKeyWhenResponseNotSaved(t *testing.T) {
+	repo := newMemoryIdempotencyRepo()
+	repo.completeErr = errors.New("connection reset")
+	next := &countingHandler{statuses: []int{http.StatusCreated}}
+	handler := newIdempotentHandler(repo, next)
+
+	sendIdempotent(handler, "key", `{}`)
+	// Без освобождения ключ оставался бы занятым, и повтор получал бы 409 до истечения срока
+	if retry := sendIdempotent(handler, "key", `{}`); retry.Code != http.StatusCreated {
+		t.Errorf("retry = %d, want 201", retry.Code)
+	}
+	if len(repo.keys) != 0 {
+		t.Errorf("keys = %v, want the key released", repo.keys)
+	}
+}
+
+func TestIdempotentReplayCarriesCurrentRequestID(t *testing.T) {
+	h := &Handler{storeUC: NewStoreUseCase(nil, nil, nil,
+		usecase.NewIdempotencyUseCase(newMemoryIdempotencyRepo(), time.Hour), nil, nil)}
+	handler := h.withRequestID(h.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		handleError(w, r, errs.ErrCustomerNotFound, "Failed to create order")
+	}))
+
+	send := func(requestID string) Problem {
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		r.Header.Set(idempotencyKeyHeader, "key")
+		r.Header.Set(requestIDHeader, requestID)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("request %s = %d, want 404", requestID, w.Code)
+		}
+		var problem Problem
+		if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+			t.Fatalf("decode problem: %v", err)
+		}
+		return problem
+	}
+
+	first := send("first-request")
+	replay := send("replayed-request")
+	if replay.RequestID != "replayed-request" {
+		t.Errorf("replay requestId = %q, want the id of the replayed request", replay.RequestID)
+	}
+	replay.RequestID = first.RequestID
+	if !reflect.DeepEqual(replay, first) {
+		t.Errorf("replay = %+v, want %+v apart from requestId", replay, first)
+	}
+}
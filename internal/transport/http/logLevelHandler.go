@@ -0,0 +1,39 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/models/transport"
+)
+
+func (h *Handler) registerLogLevelRoutes(router *mux.Router) {
+	// Уровень журнала меняет только администратор, API-ключам эти маршруты недоступны
+	router.HandleFunc("/admin/log-level", h.limited(rateLimitAdmin, authorize(h.getLogLevel, "", auth.RoleAdmin))).Methods("GET")
+	router.HandleFunc("/admin/log-level", h.limited(rateLimitAdmin, authorize(h.setLogLevel, "", auth.RoleAdmin))).Methods("PUT")
+}
+
+// getLogLevel - обработчик для получения текущего уровня журнала
+func (h *Handler) getLogLevel(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, http.StatusOK, transport.LogLevelDTO{Level: logging.Level()})
+}
+
+// setLogLevel - обработчик для смены уровня журнала без перезапуска сервиса.
+// Новый уровень действует до перезапуска, после него снова берется из конфигурации
+func (h *Handler) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	var levelDTO transport.LogLevelDTO
+	if err := decodeJSON(w, r, &levelDTO); err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	if err := logging.SetLevel(levelDTO.Level); err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid log level")
+		return
+	}
+	principal, _ := auth.FromContext(r.Context())
+	logging.FromContext(r.Context()).Warnf("Log level changed to %s by %s", logging.Level(), principal.Subject)
+
+	sendJSONResponse(w, http.StatusOK, transport.LogLevelDTO{Level: logging.Level()})
+}
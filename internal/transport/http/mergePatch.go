@@ -0,0 +1,57 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// errInvalidMergePatch возвращается, если тело PATCH-запроса не является JSON-объектом
+var errInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// applyMergePatch применяет JSON Merge Patch (RFC 7396) к JSON-документу target.
+// Поля патча со значением null удаляются из документа, вложенные объекты сливаются рекурсивно,
+// все остальные значения заменяются целиком
+func applyMergePatch(target, patch []byte) ([]byte, error) {
+	var patchValue interface{}
+	if err := decodeJSONNumber(patch, &patchValue); err != nil {
+		return nil, err
+	}
+	if _, ok := patchValue.(map[string]interface{}); !ok {
+		return nil, errInvalidMergePatch
+	}
+
+	var targetValue interface{}
+	if err := decodeJSONNumber(target, &targetValue); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatchValue(targetValue, patchValue))
+}
+
+func mergePatchValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatchValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// decodeJSONNumber декодирует JSON, сохраняя числа в виде json.Number, чтобы не терять точность
+func decodeJSONNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	// Примеры из приложения A RFC 7396, кроме тех, где патч не объект: их отклоняет applyMergePatch
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{name: "replace value", target: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "add member", target: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "remove member", target: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{name: "remove one of two", target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{name: "array replaced", target: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "value replaced by array", target: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{
+			name:   "nested merge with removal",
+			target: `{"a":{"b":"c"}}`,
+			patch:  `{"a":{"b":"d","c":null}}`,
+			want:   `{"a":{"b":"d"}}`,
+		},
+		{name: "arrays are not merged", target: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{name: "non-object target", target: `["c"]`, patch: `{"a":"b"}`, want: `{"a":"b"}`},
+		{name: "null target", target: `null`, patch: `{"a":"foo"}`, want: `{"a":"foo"}`},
+		{name: "nested null in new member", target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+		{name: "remove missing member", target: `{"e":null}`, patch: `{"a":1}`, want: `{"a":1,"e":null}`},
+		{name: "empty patch", target: `{"a":"b"}`, patch: `{}`, want: `{"a":"b"}`},
+		{
+			name:   "numbers keep precision",
+			target: `{"price":{"amount":"10.00"}}`,
+			patch:  `{"price":{"amount":12345678901234567.89}}`,
+			want:   `{"price":{"amount":12345678901234567.89}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyMergePatch([]byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("applyMergePatch unexpected error: %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("applyMergePatch(%s, %s) = %s, want %s", tt.target, tt.patch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		wantErr error
+	}{
+		{name: "array", patch: `["a"]`, wantErr: errInvalidMergePatch},
+		{name: "string", patch: `"a"`, wantErr: errInvalidMergePatch},
+		{name: "null", patch: `null`, wantErr: errInvalidMergePatch},
+		{name: "malformed", patch: `{"a":`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := applyMergePatch([]byte(`{"a":"b"}`), []byte(tt.patch))
+			if err == nil {
+				t.Fatalf("applyMergePatch(%s) succeeded, want error", tt.patch)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("applyMergePatch(%s) error = %v, want %v", tt.patch, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// jsonEqual сравнивает документы без учета порядка ключей
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var av, bv interface{}
+	if err := decodeJSONNumber(a, &av); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := decodeJSONNumber(b, &bv); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(av, bv)
+}
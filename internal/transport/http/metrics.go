@@ -0,0 +1,36 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"tages-task-go/pkg/metrics"
+	"time"
+)
+
+// instrument учитывает количество и длительность запросов по шаблону маршрута
+func (h *Handler) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r)
+
+		metrics.ObserveHTTPRequest(r.Method, routeTemplate(r), rw.status, time.Since(start))
+	})
+}
+
+// unknownRoute заменяет шаблон маршрута для запросов, не совпавших ни с одним маршрутом.
+// Путь запроса вместо него породил бы неограниченное число меток
+const unknownRoute = "unknown"
+
+// routeTemplate возвращает шаблон маршрута, с которым совпал запрос, или unknownRoute
+func routeTemplate(r *http.Request) string {
+	current := mux.CurrentRoute(r)
+	if current == nil {
+		return unknownRoute
+	}
+	template, err := current.GetPathTemplate()
+	if err != nil {
+		return unknownRoute
+	}
+	return template
+}
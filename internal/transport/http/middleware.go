@@ -0,0 +1,111 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"runtime/debug"
+	"tages-task-go/pkg/logging"
+	"time"
+)
+
+// middleware оборачивает обработчик дополнительной логикой
+type middleware func(http.Handler) http.Handler
+
+// chain оборачивает handler цепочкой middlewares. Первый в списке выполняется первым
+func chain(handler http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// responseWriter запоминает статус и размер ответа для middleware
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(data []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(data)
+	rw.bytes += n
+	return n, err
+}
+
+// Flush отправляет клиенту уже записанную часть ответа, если это умеет исходный ResponseWriter
+func (rw *responseWriter) Flush() {
+	rw.wroteHeader = true
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Unwrap возвращает исходный ResponseWriter, через него http.ResponseController находит
+// остальные возможности соединения: дедлайны, Hijack
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// recoverPanic перехватывает панику обработчика, пишет ее вместе со стеком в журнал
+// и отвечает 500, если ответ еще не начал отправляться
+func (h *Handler) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := newResponseWriter(w)
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// ErrAbortHandler - штатный способ прервать ответ, net/http обрабатывает его сам
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			logging.FromContext(r.Context()).WithField("stack", string(debug.Stack())).
+				Errorf("Panic while handling %s %s: %v", r.Method, r.URL.Path, recovered)
+			if !rw.wroteHeader {
+				writeProblem(rw, r, newProblem(r, http.StatusInternalServerError, "The server encountered an unexpected error"))
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// accessLog пишет в журнал строку о каждом обработанном запросе
+func (h *Handler) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r)
+
+		logging.FromContext(r.Context()).WithFields(map[string]interface{}{
+			"method":      r.Method,
+			"route":       routeTemplate(r),
+			"path":        r.URL.Path,
+			"status":      rw.status,
+			"bytes":       rw.bytes,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip":   clientIP(r),
+		}).Info("HTTP request served")
+	})
+}
+
+// clientIP возвращает IP-адрес клиента из адреса соединения
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -2,58 +2,97 @@ package http
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
 	"strconv"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/errs"
 	"tages-task-go/pkg/models"
 	"tages-task-go/pkg/models/transport"
 	"tages-task-go/pkg/models/usecase"
 )
 
 type OrderUseCase interface {
-	CreateOrder(ctx context.Context, order usecase.OrderUC) error
+	CreateOrder(ctx context.Context, order usecase.OrderUC) (usecase.OrderUC, error)
 	GetOrder(ctx context.Context, id int) (usecase.OrderUC, error)
-	GetAllOrders(ctx context.Context) ([]usecase.OrderUC, error)
+	ListOrders(ctx context.Context, filter usecase.OrderFilterUC) (usecase.OrderPageUC, error)
+	TransitionOrder(ctx context.Context, transition usecase.OrderTransitionUC) (usecase.OrderTransitionUC, error)
+	GetOrderTransitions(ctx context.Context, orderID int) ([]usecase.OrderTransitionUC, error)
 }
 
 func (h *Handler) registerOrderRoutes(router *mux.Router) {
-	router.HandleFunc("/orders", h.createOrder).Methods("POST")
-	router.HandleFunc("/orders", h.getAllOrders).Methods("GET")
-	router.HandleFunc("/orders/{id:[0-9]+}", h.getOrderByID).Methods("GET")
+	// Покупатель работает только со своими заказами, статусы заказов меняет ops
+	readers := []string{auth.RoleOps, auth.RoleSupport, auth.RoleCustomer}
+	router.HandleFunc("/orders", h.limited(rateLimitOrdersWrite, authorize(h.idempotent(h.createOrder), auth.ScopeOrdersWrite, auth.RoleOps, auth.RoleCustomer))).Methods("POST")
+	router.HandleFunc("/orders", h.limited(rateLimitOrdersRead, authorize(h.listOrders, auth.ScopeOrdersRead, readers...))).Methods("GET")
+	router.HandleFunc("/orders/{id:[0-9]+}", h.limited(rateLimitOrdersRead, authorize(h.getOrderByID, auth.ScopeOrdersRead, readers...))).Methods("GET")
+	router.HandleFunc("/orders/{id:[0-9]+}/transitions", h.limited(rateLimitOrdersWrite, authorize(h.createOrderTransition, auth.ScopeOrdersWrite, auth.RoleOps))).Methods("POST")
+	router.HandleFunc("/orders/{id:[0-9]+}/transitions", h.limited(rateLimitOrdersRead, authorize(h.getOrderTransitions, auth.ScopeOrdersRead, readers...))).Methods("GET")
 }
 
-// createOrder - обработчик для создания нового заказа
+// createOrder - обработчик для создания нового заказа.
+// С заголовком Idempotency-Key повторная отправка того же запроса не создает новый заказ
 func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
-	var orderDTO transport.OrderDTO
-	if err := json.NewDecoder(r.Body).Decode(&orderDTO); err != nil {
-		handleError(w, err, "Invalid request payload", http.StatusBadRequest)
+	var orderDTO transport.OrderCreateDTO
+	if err := decodeJSON(w, r, &orderDTO); err != nil {
+		handleError(w, r, err, "Invalid request payload")
 		return
 	}
 
+	if customerID, own := ownCustomerID(r); own {
+		orderDTO.CustomerID = customerID
+	}
+
 	orderUC := models.FromDtoToUseCaseOrder(orderDTO)
-	if err := h.storeUC.CreateOrder(r.Context(), orderUC); err != nil {
-		handleError(w, err, "Failed to create order", http.StatusInternalServerError)
+	created, err := h.storeUC.CreateOrder(r.Context(), orderUC)
+	if err != nil {
+		handleError(w, r, err, "Failed to create order")
 		return
 	}
 
-	sendJSONResponse(w, http.StatusCreated, map[string]string{"message": "Order created successfully"})
+	w.Header().Set("Location", fmt.Sprintf("/orders/%d", created.ID))
+	sendJSONResponse(w, http.StatusCreated, models.FromUseCaseToDtoOrder(created))
 }
 
-// getOrders - обработчик для получения всех заказов
-func (h *Handler) getAllOrders(w http.ResponseWriter, r *http.Request) {
-	ordersUC, err := h.storeUC.GetAllOrders(r.Context())
+// listOrders - обработчик для получения страницы заказов.
+// Поддерживает фильтры customer_id, product_id, status, created_from, created_to, сортировку sort и пагинацию limit/cursor
+func (h *Handler) listOrders(w http.ResponseWriter, r *http.Request) {
+	filterDTO, err := parseOrderFilter(r)
 	if err != nil {
-		handleError(w, err, "Failed to fetch orders", http.StatusInternalServerError)
+		handleError(w, r, err, "Invalid query parameters")
 		return
 	}
+	if customerID, own := ownCustomerID(r); own {
+		filterDTO.CustomerID = customerID
+	}
 
-	var ordersDTO []transport.OrderDTO
-	for _, orderUC := range ordersUC {
-		ordersDTO = append(ordersDTO, models.FromUseCaseToDtoOrder(orderUC))
+	pageUC, err := h.storeUC.ListOrders(r.Context(), models.FromDtoToUseCaseOrderFilter(filterDTO))
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch orders")
+		return
 	}
 
-	sendJSONResponse(w, http.StatusOK, ordersDTO)
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoOrderPage(pageUC))
+}
+
+// parseOrderFilter разбирает и проверяет параметры выборки заказов из строки запроса
+func parseOrderFilter(r *http.Request) (transport.OrderFilterDTO, error) {
+	query := newQueryParams(r)
+	filterDTO := transport.OrderFilterDTO{
+		CustomerID:  query.int("customer_id"),
+		ProductID:   query.int("product_id"),
+		Status:      query.string("status"),
+		CreatedFrom: query.time("created_from"),
+		CreatedTo:   query.time("created_to"),
+		Sort:        query.string("sort"),
+		Limit:       query.int("limit"),
+		Cursor:      query.string("cursor"),
+	}
+	if err := query.err(); err != nil {
+		return filterDTO, err
+	}
+	return filterDTO, validateStruct(&filterDTO)
 }
 
 // getOrderByID - обработчик для получения заказа по ID
@@ -61,16 +100,83 @@ func (h *Handler) getOrderByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		handleError(w, err, "Invalid order ID", http.StatusBadRequest)
+		handleError(w, r, invalidRequest(err), "Invalid order ID")
 		return
 	}
 
 	orderUC, err := h.storeUC.GetOrder(r.Context(), id)
 	if err != nil {
-		handleError(w, err, "Order not found", http.StatusNotFound)
+		handleError(w, r, err, "Failed to fetch order")
+		return
+	}
+	if customerID, own := ownCustomerID(r); own && orderUC.CustomerID != customerID {
+		handleError(w, r, errs.ErrOrderNotFound, "Failed to fetch order")
 		return
 	}
 
 	orderDTO := models.FromUseCaseToDtoOrder(orderUC)
 	sendJSONResponse(w, http.StatusOK, orderDTO)
 }
+
+// createOrderTransition - обработчик для перевода заказа в новый статус
+func (h *Handler) createOrderTransition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid order ID")
+		return
+	}
+
+	var transitionDTO transport.OrderTransitionCreateDTO
+	if err := decodeJSON(w, r, &transitionDTO); err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+	transitionUC := models.FromDtoToUseCaseOrderTransition(transitionDTO)
+	transitionUC.OrderID = id
+	// Автор перехода - субъект токена, а не значение из тела запроса
+	principal, _ := auth.FromContext(r.Context())
+	transitionUC.ChangedBy = principal.Subject
+
+	transitionUC, err = h.storeUC.TransitionOrder(r.Context(), transitionUC)
+	if err != nil {
+		handleError(w, r, err, "Failed to change order status")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusCreated, models.FromUseCaseToDtoOrderTransition(transitionUC))
+}
+
+// getOrderTransitions - обработчик для получения истории статусов заказа
+func (h *Handler) getOrderTransitions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid order ID")
+		return
+	}
+
+	if customerID, own := ownCustomerID(r); own {
+		orderUC, err := h.storeUC.GetOrder(r.Context(), id)
+		if err == nil && orderUC.CustomerID != customerID {
+			err = errs.ErrOrderNotFound
+		}
+		if err != nil {
+			handleError(w, r, err, "Failed to fetch order transitions")
+			return
+		}
+	}
+
+	transitionsUC, err := h.storeUC.GetOrderTransitions(r.Context(), id)
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch order transitions")
+		return
+	}
+
+	transitionsDTO := make([]transport.OrderTransitionDTO, 0, len(transitionsUC))
+	for _, transitionUC := range transitionsUC {
+		transitionsDTO = append(transitionsDTO, models.FromUseCaseToDtoOrderTransition(transitionUC))
+	}
+
+	sendJSONResponse(w, http.StatusOK, transitionsDTO)
+}
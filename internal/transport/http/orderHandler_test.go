@@ -0,0 +1,190 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tages-task-go/internal/usecase"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/models/transport"
+	"testing"
+	"time"
+)
+
+// memoryOrderRepo хранит заказы в памяти и, как репозиторий, меняет статус только из ожидаемого
+type memoryOrderRepo struct {
+	orders      map[int]*service.OrderSrv
+	transitions []service.OrderTransitionSrv
+}
+
+func newMemoryOrderRepo(orders ...service.OrderSrv) *memoryOrderRepo {
+	repo := &memoryOrderRepo{orders: make(map[int]*service.OrderSrv)}
+	for _, order := range orders {
+		repo.orders[order.ID] = &order
+	}
+	return repo
+}
+
+func (m *memoryOrderRepo) CreateOrder(_ context.Context, order *service.OrderSrv) (*service.OrderSrv, error) {
+	created := *order
+	created.ID = len(m.orders) + 1
+	created.CreatedAt = time.Now()
+	m.orders[created.ID] = &created
+	return &created, nil
+}
+
+func (m *memoryOrderRepo) GetOrderByID(_ context.Context, id int) (*service.OrderSrv, error) {
+	order, ok := m.orders[id]
+	if !ok {
+		return nil, errs.ErrOrderNotFound
+	}
+	found := *order
+	return &found, nil
+}
+
+func (m *memoryOrderRepo) ListOrders(_ context.Context, filter service.OrderFilterSrv) (service.OrderPageSrv, error) {
+	var page service.OrderPageSrv
+	for id := 1; id <= len(m.orders); id++ {
+		order, ok := m.orders[id]
+		if !ok || (filter.CustomerID != 0 && order.CustomerID != filter.CustomerID) {
+			continue
+		}
+		page.Items = append(page.Items, order)
+	}
+	return page, nil
+}
+
+func (m *memoryOrderRepo) UpdateOrderStatus(_ context.Context, transition *service.OrderTransitionSrv) error {
+	order, ok := m.orders[transition.OrderID]
+	if !ok {
+		return errs.ErrOrderNotFound
+	}
+	if order.Status != transition.FromStatus {
+		return errs.ErrStatusConflict
+	}
+	order.Status = transition.ToStatus
+	transition.ID = len(m.transitions) + 1
+	transition.ChangedAt = time.Now()
+	m.transitions = append(m.transitions, *transition)
+	return nil
+}
+
+func (m *memoryOrderRepo) GetOrderTransitions(_ context.Context, orderID int) ([]service.OrderTransitionSrv, error) {
+	var transitions []service.OrderTransitionSrv
+	for _, transition := range m.transitions {
+		if transition.OrderID == orderID {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions, nil
+}
+
+// fakeVerifier принимает токены, совпадающие с ключами карты
+type fakeVerifier map[string]auth.Principal
+
+func (v fakeVerifier) Verify(token string) (auth.Principal, error) {
+	principal, ok := v[token]
+	if !ok {
+		return auth.Principal{}, errors.New("unknown token")
+	}
+	return principal, nil
+}
+
+// testPrincipals - субъекты тестовых запросов, токен совпадает с ключом
+var testPrincipals = fakeVerifier{
+	"admin":          {Subject: "admin", Roles: []string{auth.RoleAdmin}},
+	"ops":            {Subject: "ops", Roles: []string{auth.RoleOps}},
+	"support":        {Subject: "support", Roles: []string{auth.RoleSupport}},
+	"catalog":        {Subject: "catalog", Roles: []string{auth.RoleCatalogAdmin}},
+	"customer-1":     {Subject: "customer-1", Roles: []string{auth.RoleCustomer}, CustomerID: 1},
+	"catalog-buyer":  {Subject: "catalog-buyer", Roles: []string{auth.RoleCatalogAdmin, auth.RoleCustomer}, CustomerID: 1},
+	"support-buyer":  {Subject: "support-buyer", Roles: []string{auth.RoleSupport, auth.RoleCustomer}, CustomerID: 1},
+	"admin-customer": {Subject: "admin-customer", Roles: []string{auth.RoleAdmin, auth.RoleCustomer}, CustomerID: 1},
+}
+
+// newOrderRouter возвращает маршрутизатор с заказами из repo и тестовыми субъектами
+func newOrderRouter(repo *memoryOrderRepo) http.Handler {
+	storeUC := NewStoreUseCase(usecase.NewOrderUseCase(repo), nil, nil,
+		usecase.NewIdempotencyUseCase(newMemoryIdempotencyRepo(), time.Hour), nil, nil)
+	return NewHandler(storeUC, testPrincipals, nil).InitRoutes()
+}
+
+// serve выполняет запрос от имени субъекта с токеном token, пустой токен означает запрос без аутентификации
+func serve(router http.Handler, token, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestOrderTransitions(t *testing.T) {
+	repo := newMemoryOrderRepo(service.OrderSrv{ID: 1, CustomerID: 1, Status: "pending"})
+	router := newOrderRouter(repo)
+
+	steps := []struct {
+		to         string
+		wantStatus int
+	}{
+		{to: "shipped", wantStatus: http.StatusConflict},
+		{to: "paid", wantStatus: http.StatusCreated},
+		{to: "paid", wantStatus: http.StatusConflict},
+		{to: "pending", wantStatus: http.StatusConflict},
+		{to: "lost", wantStatus: http.StatusBadRequest},
+		{to: "shipped", wantStatus: http.StatusCreated},
+		{to: "cancelled", wantStatus: http.StatusConflict},
+		{to: "delivered", wantStatus: http.StatusCreated},
+		{to: "refunded", wantStatus: http.StatusCreated},
+		{to: "cancelled", wantStatus: http.StatusConflict},
+	}
+	for _, step := range steps {
+		w := serve(router, "ops", http.MethodPost, "/orders/1/transitions", `{"to":"`+step.to+`"}`)
+		if w.Code != step.wantStatus {
+			t.Fatalf("transition to %s = %d, want %d: %s", step.to, w.Code, step.wantStatus, w.Body)
+		}
+	}
+
+	w := serve(router, "ops", http.MethodGet, "/orders/1/transitions", "")
+	var history []transport.OrderTransitionDTO
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	want := [][2]string{{"pending", "paid"}, {"paid", "shipped"}, {"shipped", "delivered"}, {"delivered", "refunded"}}
+	if len(history) != len(want) {
+		t.Fatalf("history = %+v, want %d transitions", history, len(want))
+	}
+	for i, transition := range history {
+		if transition.From != want[i][0] || transition.To != want[i][1] || transition.ChangedBy != "ops" {
+			t.Errorf("transition %d = %s -> %s by %s, want %s -> %s by ops",
+				i, transition.From, transition.To, transition.ChangedBy, want[i][0], want[i][1])
+		}
+	}
+}
+
+func TestOrderTransitionErrors(t *testing.T) {
+	router := newOrderRouter(newMemoryOrderRepo(service.OrderSrv{ID: 1, Status: "pending"}))
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "missing order", path: "/orders/9/transitions", body: `{"to":"paid"}`, wantStatus: http.StatusNotFound},
+		{name: "missing status", path: "/orders/1/transitions", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "author from body", path: "/orders/1/transitions", body: `{"to":"paid","changedBy":"someone"}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(router, "ops", http.MethodPost, tt.path, tt.body); w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"tages-task-go/pkg/errs"
+)
+
+// problemContentType - тип содержимого ответов об ошибках по RFC 7807
+const problemContentType = "application/problem+json"
+
+// Problem - описание ошибки в формате RFC 7807 (problem+json)
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError - ошибка валидации отдельного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError - ошибка валидации запроса с перечнем нарушений по полям
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Unwrap() error {
+	return errs.ErrValidation
+}
+
+// problemType описывает тип проблемы: URI и краткий заголовок, общий для всех ее экземпляров
+type problemType struct {
+	uri   string
+	title string
+}
+
+var problemTypes = map[int]problemType{
+	http.StatusBadRequest:            {uri: "/problems/validation-error", title: "Request validation failed"},
+	http.StatusUnauthorized:          {uri: "/problems/unauthorized", title: "Authentication required"},
+	http.StatusForbidden:             {uri: "/problems/forbidden", title: "Access denied"},
+	http.StatusNotFound:              {uri: "/problems/not-found", title: "Resource not found"},
+	http.StatusMethodNotAllowed:      {uri: "/problems/method-not-allowed", title: "Method not allowed"},
+	http.StatusConflict:              {uri: "/problems/conflict", title: "Resource state conflict"},
+	http.StatusRequestEntityTooLarge: {uri: "/problems/payload-too-large", title: "Request payload too large"},
+	http.StatusUnprocessableEntity:   {uri: "/problems/unprocessable-request", title: "Request cannot be processed"},
+	http.StatusTooManyRequests:       {uri: "/problems/rate-limited", title: "Too many requests"},
+	http.StatusServiceUnavailable:    {uri: "/problems/service-unavailable", title: "Service temporarily unavailable"},
+	http.StatusInternalServerError:   {uri: "/problems/internal-error", title: "Internal server error"},
+}
+
+// newProblem создает описание проблемы для указанного статуса
+func newProblem(r *http.Request, status int, detail string) Problem {
+	pt, ok := problemTypes[status]
+	if !ok {
+		pt = problemType{uri: "about:blank", title: http.StatusText(status)}
+	}
+	return Problem{
+		Type:     pt.uri,
+		Title:    pt.title,
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.RequestURI(),
+	}
+}
+
+// writeProblem отправляет описание проблемы клиенту в формате application/problem+json
+func writeProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	problem.RequestID = requestIDFromContext(r.Context())
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// notFoundHandler отвечает problem+json на запросы к несуществующим маршрутам
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, newProblem(r, http.StatusNotFound, "No route matches "+r.URL.Path))
+}
+
+// methodNotAllowedHandler отвечает problem+json на запросы с неподдерживаемым методом
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, newProblem(r, http.StatusMethodNotAllowed, "Method "+r.Method+" is not supported by "+r.URL.Path))
+}
+
+// invalidRequest помечает ошибку разбора запроса как ошибку валидации.
+// Несовпадение типа JSON-поля и неизвестные поля сообщаются как ошибки этих полей
+func invalidRequest(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return err
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &ValidationError{
+			Message: "request payload contains invalid fields",
+			Fields: []FieldError{{
+				Field:   typeErr.Field,
+				Message: "must be of type " + typeErr.Type.String(),
+			}},
+		}
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), unknownFieldPrefix), `"`)
+		return &ValidationError{
+			Message: "request payload contains invalid fields",
+			Fields:  []FieldError{{Field: field, Message: "is not a known field"}},
+		}
+	}
+	return errs.New(errs.ErrValidation, err.Error(), err)
+}
+
+// unknownFieldPrefix - префикс ошибки json.Decoder.DisallowUnknownFields, отдельного типа для нее нет
+const unknownFieldPrefix = "json: unknown field "
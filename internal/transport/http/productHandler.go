@@ -3,74 +3,259 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
 	"strconv"
+	"tages-task-go/pkg/auth"
 	"tages-task-go/pkg/models"
 	"tages-task-go/pkg/models/transport"
 	"tages-task-go/pkg/models/usecase"
 )
 
 type ProductUseCase interface {
-	CreateProduct(ctx context.Context, product usecase.ProductUC) error
+	CreateProduct(ctx context.Context, product usecase.ProductUC) (usecase.ProductUC, error)
 	GetProduct(ctx context.Context, id int) (usecase.ProductUC, error)
-	GetAllProducts(ctx context.Context) ([]usecase.ProductUC, error)
+	ListProducts(ctx context.Context, filter usecase.ProductFilterUC) (usecase.ProductPageUC, error)
+	UpdateProduct(ctx context.Context, product usecase.ProductUC) (usecase.ProductUC, error)
+	AdjustProductStock(ctx context.Context, id, delta int) (usecase.ProductUC, error)
+	DeleteProduct(ctx context.Context, id int) error
+	SearchProducts(ctx context.Context, text string, limit int) ([]usecase.ProductSearchResultUC, error)
 }
 
 func (h *Handler) registerProductRoutes(router *mux.Router) {
-	router.HandleFunc("/products", h.createProduct).Methods("POST")
-	router.HandleFunc("/products", h.getAllProducts).Methods("GET")
-	router.HandleFunc("/products/{id:[0-9]+}", h.getProductByID).Methods("GET")
+	// Каталог доступен для чтения всем аутентифицированным субъектам, менять его может только catalog_admin
+	// и API-ключи с правом products:write
+	readers := []string{auth.RoleCatalogAdmin, auth.RoleOps, auth.RoleSupport, auth.RoleCustomer}
+	router.HandleFunc("/products", h.limited(rateLimitProductsWrite, authorize(h.createProduct, auth.ScopeProductsWrite, auth.RoleCatalogAdmin))).Methods("POST")
+	router.HandleFunc("/products", h.limited(rateLimitProductsRead, authorize(h.listProducts, auth.ScopeProductsRead, readers...))).Methods("GET")
+	router.HandleFunc("/products/search", h.limited(rateLimitProductsRead, authorize(h.searchProducts, auth.ScopeProductsRead, readers...))).Methods("GET")
+	router.HandleFunc("/products/{id:[0-9]+}", h.limited(rateLimitProductsRead, authorize(h.getProductByID, auth.ScopeProductsRead, readers...))).Methods("GET")
+	router.HandleFunc("/products/{id:[0-9]+}", h.limited(rateLimitProductsWrite, authorize(h.updateProduct, auth.ScopeProductsWrite, auth.RoleCatalogAdmin))).Methods("PUT")
+	router.HandleFunc("/products/{id:[0-9]+}", h.limited(rateLimitProductsWrite, authorize(h.patchProduct, auth.ScopeProductsWrite, auth.RoleCatalogAdmin))).Methods("PATCH")
+	router.HandleFunc("/products/{id:[0-9]+}", h.limited(rateLimitProductsWrite, authorize(h.deleteProduct, auth.ScopeProductsWrite, auth.RoleCatalogAdmin))).Methods("DELETE")
+	router.HandleFunc("/products/{id:[0-9]+}/stock", h.limited(rateLimitProductsWrite, authorize(h.adjustProductStock, auth.ScopeProductsWrite, auth.RoleCatalogAdmin))).Methods("POST")
 }
 
+// productRequiredFields - поля продукта, которые нельзя пропустить или сбросить в null:
+// нулевая цена допустима, а нулевой остаток и отсутствие остатка означают разное,
+// поэтому пропуск не ловится тегами validate
+var productRequiredFields = []string{"name", "price.amount", "stock"}
+
+// productUpdateRequiredFields - поля, обязательные в PUT и после слияния в PATCH /products/{id}
+var productUpdateRequiredFields = []string{"name", "price.amount"}
+
 // createProduct - обработчик для создания нового продукта
 func (h *Handler) createProduct(w http.ResponseWriter, r *http.Request) {
-	var productDTO transport.ProductDTO
-	if err := json.NewDecoder(r.Body).Decode(&productDTO); err != nil {
-		handleError(w, err, "Invalid request payload", http.StatusBadRequest)
+	var productDTO transport.ProductCreateDTO
+	if err := decodeJSON(w, r, &productDTO, productRequiredFields...); err != nil {
+		handleError(w, r, err, "Invalid request payload")
 		return
 	}
 
 	productUC := models.FromDtoToUseCaseProduct(productDTO)
-	if err := h.storeUC.CreateProduct(r.Context(), productUC); err != nil {
-		handleError(w, err, "Failed to create product", http.StatusInternalServerError)
+	created, err := h.storeUC.CreateProduct(r.Context(), productUC)
+	if err != nil {
+		handleError(w, r, err, "Failed to create product")
 		return
 	}
 
-	sendJSONResponse(w, http.StatusCreated, map[string]string{"message": "Product created successfully"})
+	w.Header().Set("Location", fmt.Sprintf("/products/%d", created.ID))
+	sendJSONResponse(w, http.StatusCreated, models.FromUseCaseToDtoProduct(created))
 }
 
-// getAllProducts - обработчик для получения всех продуктов
-func (h *Handler) getAllProducts(w http.ResponseWriter, r *http.Request) {
-	productsUC, err := h.storeUC.GetAllProducts(r.Context())
+// listProducts - обработчик для получения страницы продуктов.
+// Поддерживает фильтры min_price, max_price, name, сортировку sort и пагинацию limit/cursor
+func (h *Handler) listProducts(w http.ResponseWriter, r *http.Request) {
+	query := newQueryParams(r)
+	filterDTO := transport.ProductFilterDTO{
+		MinPrice:     query.decimal("min_price"),
+		MaxPrice:     query.decimal("max_price"),
+		NameContains: query.string("name"),
+		Sort:         query.string("sort"),
+		Limit:        query.int("limit"),
+		Cursor:       query.string("cursor"),
+	}
+	if err := query.err(); err != nil {
+		handleError(w, r, err, "Invalid query parameters")
+		return
+	}
+	if err := validateStruct(&filterDTO); err != nil {
+		handleError(w, r, err, "Invalid query parameters")
+		return
+	}
+
+	pageUC, err := h.storeUC.ListProducts(r.Context(), models.FromDtoToUseCaseProductFilter(filterDTO))
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch products")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoProductPage(pageUC))
+}
+
+// searchProducts - обработчик для поиска продуктов по названию: GET /products/search?q=&limit=
+func (h *Handler) searchProducts(w http.ResponseWriter, r *http.Request) {
+	query := newQueryParams(r)
+	searchDTO := transport.ProductSearchDTO{
+		Query: query.string("q"),
+		Limit: query.int("limit"),
+	}
+	if err := query.err(); err != nil {
+		handleError(w, r, err, "Invalid query parameters")
+		return
+	}
+	if err := validateStruct(&searchDTO); err != nil {
+		handleError(w, r, err, "Invalid query parameters")
+		return
+	}
+
+	resultsUC, err := h.storeUC.SearchProducts(r.Context(), searchDTO.Query, searchDTO.Limit)
 	if err != nil {
-		handleError(w, err, "Failed to fetch products", http.StatusInternalServerError)
+		handleError(w, r, err, "Failed to search products")
 		return
 	}
 
-	var productsDTO []transport.ProductDTO
-	for _, productUC := range productsUC {
-		productsDTO = append(productsDTO, models.FromUseCaseToDtoProduct(productUC))
+	resultsDTO := transport.ProductSearchResultsDTO{Items: make([]transport.ProductSearchResultDTO, 0, len(resultsUC))}
+	for _, resultUC := range resultsUC {
+		resultsDTO.Items = append(resultsDTO.Items, models.FromUseCaseToDtoProductSearchResult(resultUC))
 	}
 
-	sendJSONResponse(w, http.StatusOK, productsDTO)
+	sendJSONResponse(w, http.StatusOK, resultsDTO)
 }
 
 // getProduct - обработчик для получения продукта по ID
 func (h *Handler) getProductByID(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/products/"):]
-	id, err := strconv.Atoi(idStr)
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		handleError(w, err, "Invalid product ID", http.StatusBadRequest)
+		handleError(w, r, invalidRequest(err), "Invalid product ID")
 		return
 	}
 
 	productUC, err := h.storeUC.GetProduct(r.Context(), id)
 	if err != nil {
-		handleError(w, err, "Product not found", http.StatusNotFound)
+		handleError(w, r, err, "Failed to fetch product")
 		return
 	}
 
 	productDTO := models.FromUseCaseToDtoProduct(productUC)
 	sendJSONResponse(w, http.StatusOK, productDTO)
 }
+
+// updateProduct - обработчик для полной замены продукта
+func (h *Handler) updateProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid product ID")
+		return
+	}
+
+	var updateDTO transport.ProductUpdateDTO
+	if err := decodeJSON(w, r, &updateDTO, productUpdateRequiredFields...); err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	productUC := models.FromDtoToUseCaseProductUpdate(updateDTO)
+	productUC.ID = id
+	h.saveProduct(w, r, productUC)
+}
+
+// patchProduct - обработчик для частичного обновления продукта в семантике JSON Merge Patch (RFC 7396)
+func (h *Handler) patchProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid product ID")
+		return
+	}
+
+	patch, err := readBody(w, r)
+	if err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	productUC, err := h.storeUC.GetProduct(r.Context(), id)
+	if err != nil {
+		handleError(w, r, err, "Failed to fetch product")
+		return
+	}
+
+	current, err := json.Marshal(models.FromUseCaseToDtoProductUpdate(productUC))
+	if err != nil {
+		handleError(w, r, err, "Failed to encode product")
+		return
+	}
+	patched, err := applyMergePatch(current, patch)
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid merge patch")
+		return
+	}
+
+	// Патч применяется только к изменяемым полям: остаток в патче отклоняется как неизвестное поле
+	var updateDTO transport.ProductUpdateDTO
+	if err := unmarshalAndValidate(patched, &updateDTO, productUpdateRequiredFields...); err != nil {
+		handleError(w, r, err, "Invalid merge patch")
+		return
+	}
+
+	patchedUC := models.FromDtoToUseCaseProductUpdate(updateDTO)
+	patchedUC.ID = id
+	h.saveProduct(w, r, patchedUC)
+}
+
+// saveProduct сохраняет новое состояние продукта и возвращает его клиенту
+func (h *Handler) saveProduct(w http.ResponseWriter, r *http.Request, product usecase.ProductUC) {
+	productUC, err := h.storeUC.UpdateProduct(r.Context(), product)
+	if err != nil {
+		handleError(w, r, err, "Failed to update product")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoProduct(productUC))
+}
+
+// adjustProductStock - обработчик для изменения остатка продукта на delta: поступление или списание.
+// Приращение применяется атомарно, поэтому не конфликтует со списанием остатка заказами
+func (h *Handler) adjustProductStock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid product ID")
+		return
+	}
+
+	var adjustmentDTO transport.ProductStockAdjustmentDTO
+	if err := decodeJSON(w, r, &adjustmentDTO, "delta"); err != nil {
+		handleError(w, r, err, "Invalid request payload")
+		return
+	}
+
+	productUC, err := h.storeUC.AdjustProductStock(r.Context(), id, adjustmentDTO.Delta)
+	if err != nil {
+		handleError(w, r, err, "Failed to adjust product stock")
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, models.FromUseCaseToDtoProduct(productUC))
+}
+
+// deleteProduct - обработчик для удаления продукта
+func (h *Handler) deleteProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		handleError(w, r, invalidRequest(err), "Invalid product ID")
+		return
+	}
+
+	if err := h.storeUC.DeleteProduct(r.Context(), id); err != nil {
+		handleError(w, r, err, "Failed to delete product")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
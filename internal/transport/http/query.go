@@ -0,0 +1,73 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"tages-task-go/pkg/models/money"
+	"time"
+)
+
+// queryParams разбирает параметры строки запроса и накапливает ошибки разбора по полям,
+// чтобы клиент получил их все в одном ответе
+type queryParams struct {
+	values url.Values
+	fields []FieldError
+}
+
+func newQueryParams(r *http.Request) *queryParams {
+	return &queryParams{values: r.URL.Query()}
+}
+
+func (q *queryParams) string(name string) string {
+	return q.values.Get(name)
+}
+
+// int возвращает целочисленный параметр или 0, если он не указан
+func (q *queryParams) int(name string) int {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return 0
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		q.fields = append(q.fields, FieldError{Field: name, Message: "must be an integer"})
+	}
+	return value
+}
+
+// decimal возвращает денежную сумму или nil, если параметр не указан
+func (q *queryParams) decimal(name string) *money.Decimal {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	value, err := money.ParseDecimal(raw)
+	if err != nil {
+		q.fields = append(q.fields, FieldError{Field: name, Message: "must be a decimal number with at most 2 fractional digits"})
+		return nil
+	}
+	return &value
+}
+
+// time возвращает момент времени в формате RFC 3339 или nil, если параметр не указан
+func (q *queryParams) time(name string) *time.Time {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		q.fields = append(q.fields, FieldError{Field: name, Message: "must be an RFC 3339 timestamp"})
+		return nil
+	}
+	return &value
+}
+
+// err возвращает ValidationError со всеми ошибками разбора либо nil
+func (q *queryParams) err() error {
+	if len(q.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Message: "request query contains invalid parameters", Fields: q.fields}
+}
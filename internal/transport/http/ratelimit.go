@@ -0,0 +1,82 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/errs"
+	"time"
+)
+
+// Группы маршрутов, для которых в конфигурации задаются отдельные лимиты
+const (
+	rateLimitProductsRead  = "products_read"
+	rateLimitProductsWrite = "products_write"
+	rateLimitOrdersRead    = "orders_read"
+	rateLimitOrdersWrite   = "orders_write"
+	rateLimitCustomers     = "customers"
+	rateLimitAdmin         = "admin"
+	// rateLimitAuthenticate - лимит запросов с одного IP-адреса, который применяется до аутентификации.
+	// Без него запросы без токена или с неверными токенами и API-ключами не ограничивались бы вовсе,
+	// а каждая проверка API-ключа - это запрос к базе
+	rateLimitAuthenticate = "authenticate"
+)
+
+// errRateLimited возвращается, если клиент исчерпал лимит запросов группы маршрутов
+var errRateLimited = errs.New(errs.ErrRateLimited, "too many requests from this client, retry later", nil)
+
+// limited ограничивает частоту запросов клиента к группе маршрутов group по алгоритму корзины токенов.
+// Состояние лимита сообщается заголовками RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset
+func (h *Handler) limited(group string, next http.HandlerFunc) http.HandlerFunc {
+	return h.limitedBy(group, rateLimitClient, next)
+}
+
+// limitedByIP ограничивает частоту запросов с IP-адреса клиента до аутентификации, см. rateLimitAuthenticate.
+// Заголовки RateLimit-* успешного запроса затем переписывает лимит группы маршрута
+func (h *Handler) limitedByIP(next http.Handler) http.Handler {
+	return h.limitedBy(rateLimitAuthenticate, rateLimitIP, next.ServeHTTP)
+}
+
+// limitedBy ограничивает частоту запросов к группе group, клиента определяет функция client
+func (h *Handler) limitedBy(group string, client func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.limiter.Enabled() {
+			next(w, r)
+			return
+		}
+
+		result := h.limiter.Allow(r.Context(), group, client(r))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+			handleError(w, r, errRateLimited, "Rate limit exceeded")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rateLimitClient определяет, чей лимит расходует аутентифицированный запрос: API-ключа или пользователя.
+// Маршрут без аутентификации расходует лимит IP-адреса
+func rateLimitClient(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		if principal.APIKeyID != 0 {
+			return "key:" + strconv.Itoa(principal.APIKeyID)
+		}
+		return "user:" + principal.Subject
+	}
+	return rateLimitIP(r)
+}
+
+// rateLimitIP возвращает ключ лимита по IP-адресу клиента
+func rateLimitIP(r *http.Request) string {
+	return "ip:" + clientIP(r)
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд, как требуют заголовки Retry-After и RateLimit-Reset
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"tages-task-go/pkg/logging"
+)
+
+// requestIDHeader - заголовок с идентификатором запроса
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - идентификатор клиента длиннее этого заменяется сгенерированным
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// withRequestID принимает идентификатор запроса из заголовка X-Request-ID или генерирует новый
+// и возвращает его клиенту. В контекст запроса кладется логгер с полями request_id и trace_id,
+// через который пишут журнал юзкейсы и репозитории
+func (h *Handler) withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		logger := logging.GetLogger().GetLoggerWithField("request_id", id)
+		if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+			logger = logger.GetLoggerWithField("trace_id", span.TraceID().String())
+		}
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = logging.NewContext(ctx, logger)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestIDFromContext возвращает идентификатор текущего запроса
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID проверяет, что идентификатор клиента можно без опаски писать в журнал и заголовки
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
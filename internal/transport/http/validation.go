@@ -0,0 +1,198 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"tages-task-go/pkg/models/money"
+)
+
+// maxBodyBytes - максимальный размер тела запроса
+const maxBodyBytes = 1 << 20
+
+// validate проверяет DTO по правилам из тегов validate
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// В сообщениях об ошибках используем имена полей из JSON, а не из Go
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// notblank - строка не пустая и не состоит из одних пробелов
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	// price - неотрицательная сумма с корректным кодом валюты (пустая валюта заменяется на валюту по умолчанию).
+	// Отсутствие суммы проверяется по самому JSON, см. requiredFields: нулевой Money неотличим от цены 0.00
+	v.RegisterValidation("price", func(fl validator.FieldLevel) bool {
+		price, ok := fl.Field().Interface().(money.Money)
+		if !ok {
+			return false
+		}
+		return !price.Amount.IsNegative() && (price.Currency == "" || money.IsValidCurrency(price.Currency))
+	})
+
+	return v
+}
+
+// decodeJSON читает тело запроса и декодирует его в dst, после чего проверяет dst по тегам validate.
+// Отклоняются тела больше maxBodyBytes, неизвестные поля и данные после JSON-значения,
+// а также отсутствующие или равные null поля из required, см. requiredFields
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, required ...string) error {
+	data, err := readBody(w, r)
+	if err != nil {
+		return err
+	}
+	return unmarshalAndValidate(data, dst, required...)
+}
+
+// readBody читает тело запроса, ограничивая его размер maxBodyBytes
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, invalidRequest(err)
+	}
+	return data, nil
+}
+
+// unmarshalAndValidate строго декодирует JSON в dst и проверяет его по тегам validate
+// и на наличие полей required
+func unmarshalAndValidate(data []byte, dst interface{}, required ...string) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return invalidRequest(err)
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return invalidRequest(errors.New("request body must contain a single JSON value"))
+	}
+	if err := requiredFields(data, required); err != nil {
+		return err
+	}
+	return validateStruct(dst)
+}
+
+// requiredFields проверяет, что поля с путями вида price.amount есть в документе и не равны null.
+// Нужна для полей, у которых нулевое значение допустимо и после декодирования неотличимо от пропуска.
+// Для JSON Merge Patch проверяется документ после слияния: null в патче удаляет поле
+func requiredFields(data []byte, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	var document interface{}
+	if err := decodeJSONNumber(data, &document); err != nil {
+		return invalidRequest(err)
+	}
+
+	// О пропущенном price сообщаем один раз как о price, а не как о price.amount
+	var fields []FieldError
+	reported := make(map[string]bool)
+	for _, path := range paths {
+		keys := strings.Split(path, ".")
+		value := document
+		for i, key := range keys {
+			obj, _ := value.(map[string]interface{})
+			value = obj[key]
+			if value != nil {
+				continue
+			}
+			missing := strings.Join(keys[:i+1], ".")
+			if !reported[missing] {
+				reported[missing] = true
+				fields = append(fields, FieldError{Field: missing, Message: "is required"})
+			}
+			break
+		}
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Message: "request payload contains invalid fields", Fields: fields}
+	}
+	return nil
+}
+
+// validateStruct проверяет структуру и собирает все нарушения в одну ValidationError
+func validateStruct(dst interface{}) error {
+	err := validate.Struct(dst)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return invalidRequest(err)
+	}
+
+	fields := make([]FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe),
+			Message: fieldMessage(fe),
+		})
+	}
+	return &ValidationError{Message: "request payload contains invalid fields", Fields: fields}
+}
+
+// fieldPath возвращает путь к полю без имени корневой структуры: items[0].quantity
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// fieldMessage формирует понятное клиенту описание нарушенного правила
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at most %s elements", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s elements", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "ne":
+		return "must not be " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email address"
+	case "e164":
+		return "must be a phone number in E.164 format, e.g. +79991234567"
+	case "price":
+		return "must be a non-negative amount with a valid ISO 4217 currency code"
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
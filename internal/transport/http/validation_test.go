@@ -0,0 +1,135 @@
+package http
+
+import (
+	"errors"
+	"reflect"
+	"tages-task-go/pkg/models/transport"
+	"testing"
+)
+
+func TestUnmarshalProductRequiredFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string
+	}{
+		{name: "complete", body: `{"name":"Tea","price":{"amount":"0.00","currency":"RUB"},"stock":1}`},
+		{name: "default currency", body: `{"name":"Tea","price":{"amount":"10.00"},"stock":0}`},
+		{name: "price missing", body: `{"name":"Tea","stock":0}`, wantFields: []string{"price"}},
+		{name: "price null", body: `{"name":"Tea","price":null,"stock":0}`, wantFields: []string{"price"}},
+		{name: "amount missing", body: `{"name":"Tea","price":{"currency":"RUB"},"stock":0}`, wantFields: []string{"price.amount"}},
+		{name: "amount null", body: `{"name":"Tea","price":{"amount":null},"stock":0}`, wantFields: []string{"price.amount"}},
+		{name: "stock missing", body: `{"name":"Tea","price":{"amount":"10.00"}}`, wantFields: []string{"stock"}},
+		{name: "everything missing", body: `{}`, wantFields: []string{"name", "price", "stock"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dto transport.ProductCreateDTO
+			err := unmarshalAndValidate([]byte(tt.body), &dto, productRequiredFields...)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("error = %v, want ValidationError", err)
+			}
+			var fields []string
+			for _, field := range validationErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("invalid fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestMergePatchCannotNullRequiredFields(t *testing.T) {
+	current := []byte(`{"name":"Tea","price":{"amount":"10.00","currency":"RUB"}}`)
+	tests := []struct {
+		name    string
+		patch   string
+		wantErr bool
+	}{
+		{name: "rename", patch: `{"name":"Green tea"}`},
+		{name: "null price", patch: `{"price":null}`, wantErr: true},
+		{name: "null amount", patch: `{"price":{"amount":null}}`, wantErr: true},
+		{name: "null name", patch: `{"name":null}`, wantErr: true},
+		{name: "stock", patch: `{"stock":100}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched, err := applyMergePatch(current, []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("applyMergePatch unexpected error: %v", err)
+			}
+			var dto transport.ProductUpdateDTO
+			err = unmarshalAndValidate(patched, &dto, productUpdateRequiredFields...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unmarshalAndValidate error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalStockAdjustment(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "receipt", body: `{"delta":10}`},
+		{name: "write-off", body: `{"delta":-3}`},
+		{name: "zero", body: `{"delta":0}`, wantErr: true},
+		{name: "missing", body: `{}`, wantErr: true},
+		{name: "absolute stock", body: `{"stock":10}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dto transport.ProductStockAdjustmentDTO
+			err := unmarshalAndValidate([]byte(tt.body), &dto, "delta")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unmarshalAndValidate error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalRejectsReadOnlyFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		dto       any
+		wantField string
+	}{
+		{name: "order id", body: `{"id":7,"items":[{"productId":1,"quantity":1}]}`, dto: &transport.OrderCreateDTO{}, wantField: "id"},
+		{name: "order status", body: `{"status":"paid","items":[{"productId":1,"quantity":1}]}`, dto: &transport.OrderCreateDTO{}, wantField: "status"},
+		{name: "order total", body: `{"totalPrice":{"amount":"0.00"},"items":[{"productId":1,"quantity":1}]}`, dto: &transport.OrderCreateDTO{}, wantField: "totalPrice"},
+		{name: "order created at", body: `{"createdAt":"2024-01-01T00:00:00Z","items":[{"productId":1,"quantity":1}]}`, dto: &transport.OrderCreateDTO{}, wantField: "createdAt"},
+		{name: "item price", body: `{"items":[{"productId":1,"quantity":1,"price":{"amount":"0.01"}}]}`, dto: &transport.OrderCreateDTO{}, wantField: "price"},
+		{name: "transition author", body: `{"to":"paid","changedBy":"someone"}`, dto: &transport.OrderTransitionCreateDTO{}, wantField: "changedBy"},
+		{name: "transition from", body: `{"to":"paid","from":"pending"}`, dto: &transport.OrderTransitionCreateDTO{}, wantField: "from"},
+		{name: "customer id", body: `{"id":5,"name":"Ann","email":"ann@example.com"}`, dto: &transport.CustomerInputDTO{}, wantField: "id"},
+		{name: "customer created at", body: `{"name":"Ann","email":"ann@example.com","createdAt":"2024-01-01T00:00:00Z"}`,
+			dto: &transport.CustomerInputDTO{}, wantField: "createdAt"},
+		{name: "api key value", body: `{"name":"billing","scopes":["orders:read"],"key":"chosen-by-client"}`,
+			dto: &transport.APIKeyInputDTO{}, wantField: "key"},
+		{name: "product id", body: `{"id":3,"name":"Tea","price":{"amount":"1.00"},"stock":0}`, dto: &transport.ProductCreateDTO{}, wantField: "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := unmarshalAndValidate([]byte(tt.body), tt.dto)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("error = %v, want ValidationError", err)
+			}
+			if len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != tt.wantField {
+				t.Errorf("invalid fields = %+v, want %s", validationErr.Fields, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"tages-task-go/pkg/auth"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/models"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/models/usecase"
+	"tages-task-go/pkg/tracing"
+	"time"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key service.APIKeySrv) (service.APIKeySrv, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (service.APIKeySrv, error)
+	ListAPIKeys(ctx context.Context) ([]service.APIKeySrv, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	TouchAPIKey(ctx context.Context, id int) error
+}
+
+// Формат ключа: tk_<префикс>_<секрет>. Префикс открыт и служит для поиска ключа,
+// секрет известен только клиенту, в базе хранится его SHA-256
+const (
+	apiKeyMarker      = "tk_"
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+var (
+	// ErrInvalidAPIKey возвращается для неизвестного, отозванного или истекшего API-ключа
+	ErrInvalidAPIKey = errs.New(errs.ErrUnauthorized, "api key is invalid, revoked or expired", nil)
+	// ErrInvalidScope возвращается при выпуске ключа с неизвестным правом
+	ErrInvalidScope = errs.New(errs.ErrValidation, "unknown api key scope", nil)
+)
+
+type apiKeyUC struct {
+	repo APIKeyRepository
+}
+
+func NewAPIKeyUseCase(repo APIKeyRepository) *apiKeyUC {
+	return &apiKeyUC{repo: repo}
+}
+
+// hashAPIKeySecret возвращает хэш секрета, который хранится в базе
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueAPIKey выпускает новый ключ. Ключ целиком возвращается только в ответе на этот вызов
+func (a *apiKeyUC) IssueAPIKey(ctx context.Context, key usecase.APIKeyUC) (usecase.APIKeyUC, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyUseCase.IssueAPIKey")
+	defer span.End()
+
+	if len(key.Scopes) == 0 {
+		return usecase.APIKeyUC{}, errs.New(errs.ErrValidation, "api key must have at least one scope", ErrInvalidScope)
+	}
+	for _, scope := range key.Scopes {
+		if !auth.IsValidScope(scope) {
+			return usecase.APIKeyUC{}, errs.New(errs.ErrValidation,
+				fmt.Sprintf("unknown api key scope %q", scope), ErrInvalidScope)
+		}
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return usecase.APIKeyUC{}, errs.New(errs.ErrValidation, "api key expiry must be in the future", nil)
+	}
+
+	prefix := make([]byte, apiKeyPrefixBytes)
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(prefix); err != nil {
+		return usecase.APIKeyUC{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return usecase.APIKeyUC{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	secretStr := base64.RawURLEncoding.EncodeToString(secret)
+
+	keySrv := service.APIKeySrv{
+		Name:       strings.TrimSpace(key.Name),
+		Prefix:     hex.EncodeToString(prefix),
+		SecretHash: hashAPIKeySecret(secretStr),
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		CreatedBy:  key.CreatedBy,
+	}
+	created, err := a.repo.CreateAPIKey(ctx, keySrv)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create api key: ", err)
+		return usecase.APIKeyUC{}, fmt.Errorf("failed to create api key: %w", err)
+	}
+	logging.FromContext(ctx).Infof("API key %d (%s) issued by %s with scopes %v", created.ID, created.Prefix, created.CreatedBy, created.Scopes)
+
+	issued := models.FromServiceToUseCaseAPIKey(created)
+	issued.Key = apiKeyMarker + created.Prefix + "_" + secretStr
+	return issued, nil
+}
+
+// ListAPIKeys возвращает все ключи без секретов
+func (a *apiKeyUC) ListAPIKeys(ctx context.Context) ([]usecase.APIKeyUC, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyUseCase.ListAPIKeys")
+	defer span.End()
+
+	keysSrv, err := a.repo.ListAPIKeys(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list api keys: ", err)
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	keysUC := make([]usecase.APIKeyUC, 0, len(keysSrv))
+	for _, keySrv := range keysSrv {
+		keysUC = append(keysUC, models.FromServiceToUseCaseAPIKey(keySrv))
+	}
+	return keysUC, nil
+}
+
+// RevokeAPIKey отзывает ключ, после чего он перестает проходить аутентификацию
+func (a *apiKeyUC) RevokeAPIKey(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "apiKeyUseCase.RevokeAPIKey")
+	defer span.End()
+
+	if err := a.repo.RevokeAPIKey(ctx, id); err != nil {
+		logging.FromContext(ctx).Error("Failed to revoke api key: ", err)
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	logging.FromContext(ctx).Info("API key revoked by ID:", id)
+	return nil
+}
+
+// AuthenticateAPIKey проверяет ключ из запроса и возвращает его описание.
+// Причина отказа пишется в лог, клиент получает одну и ту же ErrInvalidAPIKey
+func (a *apiKeyUC) AuthenticateAPIKey(ctx context.Context, rawKey string) (usecase.APIKeyUC, error) {
+	ctx, span := tracing.Start(ctx, "apiKeyUseCase.AuthenticateAPIKey")
+	defer span.End()
+
+	prefix, secret, found := strings.Cut(strings.TrimPrefix(rawKey, apiKeyMarker), "_")
+	if !strings.HasPrefix(rawKey, apiKeyMarker) || !found || prefix == "" || secret == "" {
+		logging.FromContext(ctx).Warn("Rejected malformed api key")
+		return usecase.APIKeyUC{}, ErrInvalidAPIKey
+	}
+
+	keySrv, err := a.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, errs.ErrAPIKeyNotFound) {
+		logging.FromContext(ctx).Warnf("Rejected unknown api key %s", prefix)
+		return usecase.APIKeyUC{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get api key: ", err)
+		return usecase.APIKeyUC{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	switch {
+	case subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(keySrv.SecretHash)) != 1:
+		logging.FromContext(ctx).Warnf("Rejected api key %s with a wrong secret", prefix)
+		return usecase.APIKeyUC{}, ErrInvalidAPIKey
+	case keySrv.RevokedAt != nil:
+		logging.FromContext(ctx).Warnf("Rejected revoked api key %s", prefix)
+		return usecase.APIKeyUC{}, ErrInvalidAPIKey
+	case keySrv.ExpiresAt != nil && !keySrv.ExpiresAt.After(time.Now()):
+		logging.FromContext(ctx).Warnf("Rejected expired api key %s", prefix)
+		return usecase.APIKeyUC{}, ErrInvalidAPIKey
+	}
+
+	// Ошибка учета последнего использования не должна мешать запросу
+	if err := a.repo.TouchAPIKey(ctx, keySrv.ID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record api key use: ", err)
+	}
+	return models.FromServiceToUseCaseAPIKey(keySrv), nil
+}
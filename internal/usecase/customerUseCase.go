@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/models"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/models/usecase"
+	"tages-task-go/pkg/tracing"
+)
+
+type CustomerRepository interface {
+	CreateCustomer(ctx context.Context, customer service.CustomerSrv) (service.CustomerSrv, error)
+	GetCustomerByID(ctx context.Context, id int) (service.CustomerSrv, error)
+	ListCustomers(ctx context.Context, filter service.CustomerFilterSrv) (service.CustomerPageSrv, error)
+	UpdateCustomer(ctx context.Context, customer service.CustomerSrv) (service.CustomerSrv, error)
+	DeleteCustomer(ctx context.Context, id int) error
+}
+
+type customerUC struct {
+	repo      CustomerRepository
+	orderRepo OrderRepository
+}
+
+func NewCustomerUseCase(repo CustomerRepository, orderRepo OrderRepository) *customerUC {
+	return &customerUC{repo: repo, orderRepo: orderRepo}
+}
+
+// normalizeCustomer убирает лишние пробелы и приводит email к нижнему регистру
+func normalizeCustomer(customer *usecase.CustomerUC) {
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.Email = strings.ToLower(strings.TrimSpace(customer.Email))
+	customer.Phone = strings.TrimSpace(customer.Phone)
+}
+
+func (c *customerUC) CreateCustomer(ctx context.Context, customer usecase.CustomerUC) (usecase.CustomerUC, error) {
+	ctx, span := tracing.Start(ctx, "customerUseCase.CreateCustomer")
+	defer span.End()
+
+	normalizeCustomer(&customer)
+	created, err := c.repo.CreateCustomer(ctx, models.FromUseCaseToServiceCustomer(customer))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create customer: ", err)
+		return usecase.CustomerUC{}, fmt.Errorf("failed to create customer: %w", err)
+	}
+	logging.FromContext(ctx).Info("Customer created successfully with ID:", created.ID)
+	return models.FromServiceToUseCaseCustomer(created), nil
+}
+
+func (c *customerUC) GetCustomer(ctx context.Context, id int) (usecase.CustomerUC, error) {
+	ctx, span := tracing.Start(ctx, "customerUseCase.GetCustomer")
+	defer span.End()
+
+	customerSrv, err := c.repo.GetCustomerByID(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get customer by ID: ", err)
+		return usecase.CustomerUC{}, fmt.Errorf("failed to get customer: %w", err)
+	}
+	logging.FromContext(ctx).Info("Customer retrieved successfully by ID:", id)
+	return models.FromServiceToUseCaseCustomer(customerSrv), nil
+}
+
+// ListCustomers возвращает страницу покупателей, отобранных и отсортированных по фильтру
+func (c *customerUC) ListCustomers(ctx context.Context, filter usecase.CustomerFilterUC) (usecase.CustomerPageUC, error) {
+	ctx, span := tracing.Start(ctx, "customerUseCase.ListCustomers")
+	defer span.End()
+
+	limit, err := pageLimit(filter.Limit)
+	if err != nil {
+		return usecase.CustomerPageUC{}, err
+	}
+	filter.Limit = limit
+	filter.Email = strings.TrimSpace(filter.Email)
+
+	pageSrv, err := c.repo.ListCustomers(ctx, models.FromUseCaseToServiceCustomerFilter(filter))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list customers: ", err)
+		return usecase.CustomerPageUC{}, fmt.Errorf("failed to list customers: %w", err)
+	}
+	logging.FromContext(ctx).Infof("Listed %d customers", len(pageSrv.Items))
+	return models.FromServiceToUseCaseCustomerPage(pageSrv), nil
+}
+
+func (c *customerUC) UpdateCustomer(ctx context.Context, customer usecase.CustomerUC) (usecase.CustomerUC, error) {
+	ctx, span := tracing.Start(ctx, "customerUseCase.UpdateCustomer")
+	defer span.End()
+
+	normalizeCustomer(&customer)
+	updated, err := c.repo.UpdateCustomer(ctx, models.FromUseCaseToServiceCustomer(customer))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update customer: ", err)
+		return usecase.CustomerUC{}, fmt.Errorf("failed to update customer: %w", err)
+	}
+	logging.FromContext(ctx).Info("Customer updated successfully by ID:", customer.ID)
+	return models.FromServiceToUseCaseCustomer(updated), nil
+}
+
+func (c *customerUC) DeleteCustomer(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "customerUseCase.DeleteCustomer")
+	defer span.End()
+
+	if err := c.repo.DeleteCustomer(ctx, id); err != nil {
+		logging.FromContext(ctx).Error("Failed to delete customer: ", err)
+		return fmt.Errorf("failed to delete customer: %w", err)
+	}
+	logging.FromContext(ctx).Info("Customer deleted successfully by ID:", id)
+	return nil
+}
+
+// ListCustomerOrders возвращает страницу истории заказов покупателя
+func (c *customerUC) ListCustomerOrders(ctx context.Context, customerID int, filter usecase.OrderFilterUC) (usecase.OrderPageUC, error) {
+	ctx, span := tracing.Start(ctx, "customerUseCase.ListCustomerOrders")
+	defer span.End()
+
+	if _, err := c.repo.GetCustomerByID(ctx, customerID); err != nil {
+		logging.FromContext(ctx).Error("Failed to get customer by ID: ", err)
+		return usecase.OrderPageUC{}, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	filter.CustomerID = customerID
+	if err := normalizeOrderFilter(&filter); err != nil {
+		return usecase.OrderPageUC{}, err
+	}
+	pageSrv, err := c.orderRepo.ListOrders(ctx, models.FromUseCaseToServiceOrderFilter(filter))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list customer orders: ", err)
+		return usecase.OrderPageUC{}, fmt.Errorf("failed to list customer orders: %w", err)
+	}
+	logging.FromContext(ctx).Infof("Listed %d orders of customer %d", len(pageSrv.Items), customerID)
+	return models.FromServiceToUseCaseOrderPage(pageSrv), nil
+}
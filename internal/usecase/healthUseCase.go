@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/models/usecase"
+	"time"
+)
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (uint, bool, error)
+	LatestMigrationVersion() (uint, error)
+}
+
+// checkTimeout - сколько ждать ответа базы при проверке готовности
+const checkTimeout = 2 * time.Second
+
+type healthUC struct {
+	repo         HealthRepository
+	shuttingDown atomic.Bool
+}
+
+func NewHealthUseCase(repo HealthRepository) *healthUC {
+	return &healthUC{repo: repo}
+}
+
+// BeginShutdown помечает сервис как не готовый принимать запросы.
+// После этого проверка готовности завершается неудачей, и балансировщик перестает направлять запросы
+func (h *healthUC) BeginShutdown(ctx context.Context) {
+	if !h.shuttingDown.Swap(true) {
+		logging.FromContext(ctx).Info("Shutdown started, readiness check will fail from now on")
+	}
+}
+
+// Readiness проверяет компоненты, без которых сервис не может обслуживать запросы:
+// доступность базы, актуальность схемы и отсутствие начатого завершения работы
+func (h *healthUC) Readiness(ctx context.Context) usecase.HealthReportUC {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	report := usecase.HealthReportUC{
+		Status: usecase.HealthStatusUp,
+		Components: map[string]usecase.ComponentHealthUC{
+			"database":   checkComponent(func() error { return h.repo.Ping(ctx) }),
+			"migrations": checkComponent(func() error { return h.checkMigrations(ctx) }),
+			"shutdown":   checkComponent(h.checkShutdown),
+		},
+	}
+	for name, component := range report.Components {
+		if component.Status != usecase.HealthStatusUp {
+			report.Status = usecase.HealthStatusDown
+			logging.FromContext(ctx).Warnf("Readiness check %s failed: %v", name, component.Err)
+		}
+	}
+	return report
+}
+
+// checkMigrations проверяет, что к базе применены все миграции сервиса и ни одна не прервана
+func (h *healthUC) checkMigrations(ctx context.Context) error {
+	latest, err := h.repo.LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+	version, dirty, err := h.repo.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty and needs manual repair", version)
+	}
+	if version != latest {
+		return fmt.Errorf("schema version is %d, expected %d", version, latest)
+	}
+	return nil
+}
+
+func (h *healthUC) checkShutdown() error {
+	if h.shuttingDown.Load() {
+		return fmt.Errorf("server is shutting down")
+	}
+	return nil
+}
+
+// checkComponent выполняет проверку и замеряет ее длительность
+func checkComponent(check func() error) usecase.ComponentHealthUC {
+	start := time.Now()
+	err := check()
+	component := usecase.ComponentHealthUC{Status: usecase.HealthStatusUp, Duration: time.Since(start)}
+	if err != nil {
+		component.Status = usecase.HealthStatusDown
+		component.Err = err
+	}
+	return component
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"tages-task-go/pkg/errs"
+	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/models"
+	"tages-task-go/pkg/models/service"
+	"tages-task-go/pkg/models/usecase"
+	"tages-task-go/pkg/tracing"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, key service.IdempotencyKeySrv) (service.IdempotencyKeySrv, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, key service.IdempotencyKeySrv) error
+	DeleteIdempotencyKey(ctx context.Context, scope, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+var (
+	// ErrIdempotencyKeyReused возвращается, если ключ идемпотентности повторно использован с другим телом запроса
+	ErrIdempotencyKeyReused = errs.New(errs.ErrUnprocessable,
+		"idempotency key was already used with a different request payload", nil)
+	// ErrIdempotencyKeyInProgress возвращается, если запрос с этим ключом еще обрабатывается
+	ErrIdempotencyKeyInProgress = errs.New(errs.ErrConflict,
+		"a request with this idempotency key is still in progress", nil)
+)
+
+type idempotencyUC struct {
+	repo IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyUseCase создает юзкейс ключей идемпотентности, ключи хранятся в течение ttl
+func NewIdempotencyUseCase(repo IdempotencyRepository, ttl time.Duration) *idempotencyUC {
+	return &idempotencyUC{repo: repo, ttl: ttl}
+}
+
+// BeginIdempotentRequest занимает ключ запроса. Если запрос с этим ключом уже выполнен,
+// возвращается сохраненный ответ; nil означает, что запрос нужно выполнить
+func (i *idempotencyUC) BeginIdempotentRequest(ctx context.Context, request usecase.IdempotentRequestUC) (*usecase.IdempotentResponseUC, error) {
+	ctx, span := tracing.Start(ctx, "idempotencyUseCase.BeginIdempotentRequest")
+	defer span.End()
+
+	keySrv := service.IdempotencyKeySrv{
+		Scope:       request.Scope,
+		Key:         request.Key,
+		RequestHash: request.RequestHash,
+		ExpiresAt:   time.Now().Add(i.ttl),
+	}
+	existing, reserved, err := i.repo.ReserveIdempotencyKey(ctx, keySrv)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to reserve idempotency key: ", err)
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	switch {
+	case existing.RequestHash != request.RequestHash:
+		logging.FromContext(ctx).Warnf("Idempotency key %q for %s reused with a different payload", request.Key, request.Scope)
+		return nil, ErrIdempotencyKeyReused
+	case existing.StatusCode == 0:
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	logging.FromContext(ctx).Infof("Replaying response for idempotency key %q for %s", request.Key, request.Scope)
+	response := models.FromServiceToUseCaseIdempotentResponse(existing)
+	return &response, nil
+}
+
+// CompleteIdempotentRequest сохраняет ответ на запрос, чтобы возвращать его на повторы с тем же ключом
+func (i *idempotencyUC) CompleteIdempotentRequest(ctx context.Context, request usecase.IdempotentRequestUC, response usecase.IdempotentResponseUC) error {
+	ctx, span := tracing.Start(ctx, "idempotencyUseCase.CompleteIdempotentRequest")
+	defer span.End()
+
+	if err := i.repo.CompleteIdempotencyKey(ctx, models.FromUseCaseToServiceIdempotencyKey(request, response)); err != nil {
+		logging.FromContext(ctx).Error("Failed to save idempotent response: ", err)
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotentRequest освобождает ключ запроса, завершившегося без сохраняемого ответа
+func (i *idempotencyUC) ReleaseIdempotentRequest(ctx context.Context, request usecase.IdempotentRequestUC) error {
+	ctx, span := tracing.Start(ctx, "idempotencyUseCase.ReleaseIdempotentRequest")
+	defer span.End()
+
+	if err := i.repo.DeleteIdempotencyKey(ctx, request.Scope, request.Key); err != nil {
+		logging.FromContext(ctx).Error("Failed to release idempotency key: ", err)
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredKeys удаляет истекшие ключи. Без этого ключи, которые не приходят повторно,
+// хранились бы вместе с телами ответов бессрочно
+func (i *idempotencyUC) PurgeExpiredKeys(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "idempotencyUseCase.PurgeExpiredKeys")
+	defer span.End()
+
+	deleted, err := i.repo.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to purge expired idempotency keys: ", err)
+		return fmt.Errorf("failed to purge expired idempotency keys: %w", err)
+	}
+	if deleted > 0 {
+		logging.FromContext(ctx).Infof("Purged %d expired idempotency keys", deleted)
+	}
+	return nil
+}
+
+// RunPurger удаляет истекшие ключи каждые interval, пока не отменен ctx. Ошибки только логируются:
+// очистка повторится на следующем тике
+func (i *idempotencyUC) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			i.PurgeExpiredKeys(ctx)
+		}
+	}
+}
@@ -3,10 +3,14 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"tages-task-go/pkg/errs"
 	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/metrics"
 	"tages-task-go/pkg/models"
 	"tages-task-go/pkg/models/service"
 	"tages-task-go/pkg/models/usecase"
+	"tages-task-go/pkg/tracing"
 )
 
 //type OrderUseCase interface {
@@ -16,63 +20,226 @@ import (
 //}
 
 type OrderRepository interface {
-	CreateOrder(ctx context.Context, order *service.OrderSrv) error
+	CreateOrder(ctx context.Context, order *service.OrderSrv) (*service.OrderSrv, error)
 	GetOrderByID(ctx context.Context, id int) (*service.OrderSrv, error)
-	GetAllOrders(ctx context.Context) ([]*service.OrderSrv, error)
+	ListOrders(ctx context.Context, filter service.OrderFilterSrv) (service.OrderPageSrv, error)
+	UpdateOrderStatus(ctx context.Context, transition *service.OrderTransitionSrv) error
+	GetOrderTransitions(ctx context.Context, orderID int) ([]service.OrderTransitionSrv, error)
+}
+
+// Ошибки бизнес-правил заказа относятся к категориям errs,
+// поэтому транспортный слой сопоставляет их HTTP-статусам так же, как ошибки репозитория
+var (
+	// ErrEmptyOrder возвращается при попытке создать заказ без позиций
+	ErrEmptyOrder = errs.New(errs.ErrValidation, "order must contain at least one item", nil)
+	// ErrInvalidQuantity возвращается для позиции заказа с неположительным количеством
+	ErrInvalidQuantity = errs.New(errs.ErrValidation, "order item quantity must be positive", nil)
+	// ErrUnknownOrderStatus возвращается для статуса, не входящего в жизненный цикл заказа
+	ErrUnknownOrderStatus = errs.New(errs.ErrValidation, "unknown order status", nil)
+	// ErrMissingActor возвращается, если не указано, кто меняет статус заказа
+	ErrMissingActor = errs.New(errs.ErrValidation, "transition author is required", nil)
+)
+
+// InvalidTransitionError возвращается при попытке недопустимого перехода между статусами заказа
+type InvalidTransitionError struct {
+	From usecase.OrderStatus
+	To   usecase.OrderStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("order cannot transition from %q to %q", e.From, e.To)
+}
+
+// orderTransitions описывает конечный автомат статусов заказа: для каждого статуса
+// перечислены статусы, в которые из него можно перейти. Статусы cancelled и refunded конечные
+var orderTransitions = map[usecase.OrderStatus][]usecase.OrderStatus{
+	usecase.OrderStatusPending:   {usecase.OrderStatusPaid, usecase.OrderStatusCancelled},
+	usecase.OrderStatusPaid:      {usecase.OrderStatusShipped, usecase.OrderStatusCancelled, usecase.OrderStatusRefunded},
+	usecase.OrderStatusShipped:   {usecase.OrderStatusDelivered},
+	usecase.OrderStatusDelivered: {usecase.OrderStatusRefunded},
+	usecase.OrderStatusCancelled: {},
+	usecase.OrderStatusRefunded:  {},
+}
+
+// canTransition проверяет, разрешен ли переход заказа из статуса from в статус to
+func canTransition(from, to usecase.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
 }
 
 type orderUC struct {
-	repo   OrderRepository
-	logger *logging.Logger
+	repo OrderRepository
 }
 
-func NewOrderUseCase(repo OrderRepository, logger *logging.Logger) *orderUC {
-	return &orderUC{repo: repo, logger: logger}
+func NewOrderUseCase(repo OrderRepository) *orderUC {
+	return &orderUC{repo: repo}
 }
 
-func (o *orderUC) CreateOrder(ctx context.Context, order usecase.OrderUC) error {
+// CreateOrder создает заказ и учитывает результат в метриках заказов
+func (o *orderUC) CreateOrder(ctx context.Context, order usecase.OrderUC) (usecase.OrderUC, error) {
+	ctx, span := tracing.Start(ctx, "orderUseCase.CreateOrder")
+	defer span.End()
+
+	created, err := o.createOrder(ctx, order)
+	if err != nil {
+		metrics.OrderFailed(orderFailureReason(err))
+		return usecase.OrderUC{}, err
+	}
+	metrics.OrderCreated(created.TotalPrice)
+	return created, nil
+}
+
+// orderFailureReason возвращает причину неудачи создания заказа для метрик
+func orderFailureReason(err error) string {
+	var stockErr *errs.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		return "insufficient_stock"
+	case errors.Is(err, errs.ErrValidation):
+		return "validation"
+	case errors.Is(err, errs.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, errs.ErrConflict), errors.Is(err, errs.ErrUnprocessable):
+		return "conflict"
+	case errors.Is(err, errs.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
+
+func (o *orderUC) createOrder(ctx context.Context, order usecase.OrderUC) (usecase.OrderUC, error) {
+	if len(order.Items) == 0 {
+		return usecase.OrderUC{}, ErrEmptyOrder
+	}
+	for _, item := range order.Items {
+		if item.Quantity <= 0 {
+			return usecase.OrderUC{}, errs.New(errs.ErrValidation,
+				fmt.Sprintf("quantity of product %d must be positive", item.ProductID), ErrInvalidQuantity)
+		}
+	}
+
+	order.Status = usecase.OrderStatusPending
 	orderSrv := models.FromUseCaseToServiceOrder(order)
 
-	if err := o.repo.CreateOrder(ctx, &orderSrv); err != nil {
-		o.logger.Error("Failed to create order: ", err)
-		return errors.New("failed to create order: " + err.Error())
+	created, err := o.repo.CreateOrder(ctx, &orderSrv)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create order: ", err)
+		return usecase.OrderUC{}, fmt.Errorf("failed to create order: %w", err)
 	}
-	o.logger.Info("Order created successfully")
-	return nil
+	logging.FromContext(ctx).Info("Order created successfully with ID:", created.ID)
+	return models.FromServiceToUseCaseOrder(*created), nil
 }
 
 func (o *orderUC) GetOrder(ctx context.Context, id int) (usecase.OrderUC, error) {
+	ctx, span := tracing.Start(ctx, "orderUseCase.GetOrder")
+	defer span.End()
+
 	orderSrv, err := o.repo.GetOrderByID(ctx, id)
 	if err != nil {
-		o.logger.Error("Failed to get order by ID: ", err)
-		return usecase.OrderUC{}, errors.New("failed to get order: " + err.Error())
+		logging.FromContext(ctx).Error("Failed to get order by ID: ", err)
+		return usecase.OrderUC{}, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	orderUC := usecase.OrderUC{
-		ID:        orderSrv.ID,
-		ProductID: orderSrv.ProductID,
-		Quantity:  orderSrv.Quantity,
-	}
-	o.logger.Info("Order retrieved successfully by ID:", id)
+	orderUC := models.FromServiceToUseCaseOrder(*orderSrv)
+	logging.FromContext(ctx).Info("Order retrieved successfully by ID:", id)
 	return orderUC, nil
 }
 
-func (o *orderUC) GetAllOrders(ctx context.Context) ([]usecase.OrderUC, error) {
-	ordersSrv, err := o.repo.GetAllOrders(ctx)
+// ListOrders возвращает страницу заказов, отобранных и отсортированных по фильтру
+func (o *orderUC) ListOrders(ctx context.Context, filter usecase.OrderFilterUC) (usecase.OrderPageUC, error) {
+	ctx, span := tracing.Start(ctx, "orderUseCase.ListOrders")
+	defer span.End()
+
+	if err := normalizeOrderFilter(&filter); err != nil {
+		return usecase.OrderPageUC{}, err
+	}
+
+	pageSrv, err := o.repo.ListOrders(ctx, models.FromUseCaseToServiceOrderFilter(filter))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list orders: ", err)
+		return usecase.OrderPageUC{}, fmt.Errorf("failed to list orders: %w", err)
+	}
+	logging.FromContext(ctx).Infof("Listed %d orders", len(pageSrv.Items))
+	return models.FromServiceToUseCaseOrderPage(pageSrv), nil
+}
+
+// normalizeOrderFilter подставляет размер страницы по умолчанию и проверяет фильтр заказов
+func normalizeOrderFilter(filter *usecase.OrderFilterUC) error {
+	limit, err := pageLimit(filter.Limit)
 	if err != nil {
-		o.logger.Error("Failed to get all orders: ", err)
-		return nil, errors.New("failed to get orders: " + err.Error())
+		return err
+	}
+	filter.Limit = limit
+	if _, ok := orderTransitions[filter.Status]; filter.Status != "" && !ok {
+		return errs.New(errs.ErrValidation,
+			fmt.Sprintf("unknown order status %q", filter.Status), ErrUnknownOrderStatus)
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return errs.New(errs.ErrValidation, "created_from must be earlier than created_to", nil)
 	}
+	return nil
+}
 
-	var ordersUC []usecase.OrderUC
-	for _, orderSrv := range ordersSrv {
-		orderUC := usecase.OrderUC{
-			ID:        orderSrv.ID,
-			ProductID: orderSrv.ProductID,
-			Quantity:  orderSrv.Quantity,
-		}
-		ordersUC = append(ordersUC, orderUC)
+// TransitionOrder переводит заказ в новый статус, если это разрешено жизненным циклом заказа
+func (o *orderUC) TransitionOrder(ctx context.Context, transition usecase.OrderTransitionUC) (usecase.OrderTransitionUC, error) {
+	ctx, span := tracing.Start(ctx, "orderUseCase.TransitionOrder")
+	defer span.End()
+
+	if _, ok := orderTransitions[transition.To]; !ok {
+		return usecase.OrderTransitionUC{}, errs.New(errs.ErrValidation,
+			fmt.Sprintf("unknown order status %q", transition.To), ErrUnknownOrderStatus)
+	}
+	if transition.ChangedBy == "" {
+		return usecase.OrderTransitionUC{}, ErrMissingActor
+	}
+
+	orderSrv, err := o.repo.GetOrderByID(ctx, transition.OrderID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get order by ID: ", err)
+		return usecase.OrderTransitionUC{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	transition.From = usecase.OrderStatus(orderSrv.Status)
+	if !canTransition(transition.From, transition.To) {
+		logging.FromContext(ctx).Warnf("Rejected order %d transition from %s to %s", transition.OrderID, transition.From, transition.To)
+		invalidErr := &InvalidTransitionError{From: transition.From, To: transition.To}
+		return usecase.OrderTransitionUC{}, errs.New(errs.ErrConflict, invalidErr.Error(), invalidErr)
+	}
+
+	transitionSrv := models.FromUseCaseToServiceOrderTransition(transition)
+	if err := o.repo.UpdateOrderStatus(ctx, &transitionSrv); err != nil {
+		logging.FromContext(ctx).Error("Failed to update order status: ", err)
+		return usecase.OrderTransitionUC{}, fmt.Errorf("failed to update order status: %w", err)
+	}
+	logging.FromContext(ctx).Infof("Order %d moved from %s to %s by %s", transition.OrderID, transition.From, transition.To, transition.ChangedBy)
+	return models.FromServiceToUseCaseOrderTransition(transitionSrv), nil
+}
+
+// GetOrderTransitions возвращает историю изменения статусов заказа
+func (o *orderUC) GetOrderTransitions(ctx context.Context, orderID int) ([]usecase.OrderTransitionUC, error) {
+	ctx, span := tracing.Start(ctx, "orderUseCase.GetOrderTransitions")
+	defer span.End()
+
+	if _, err := o.repo.GetOrderByID(ctx, orderID); err != nil {
+		logging.FromContext(ctx).Error("Failed to get order by ID: ", err)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	transitionsSrv, err := o.repo.GetOrderTransitions(ctx, orderID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get order transitions: ", err)
+		return nil, fmt.Errorf("failed to get order transitions: %w", err)
+	}
+
+	transitionsUC := make([]usecase.OrderTransitionUC, 0, len(transitionsSrv))
+	for _, transitionSrv := range transitionsSrv {
+		transitionsUC = append(transitionsUC, models.FromServiceToUseCaseOrderTransition(transitionSrv))
 	}
-	o.logger.Info("All orders retrieved successfully")
-	return ordersUC, nil
+	logging.FromContext(ctx).Info("Order transitions retrieved successfully by order ID:", orderID)
+	return transitionsUC, nil
 }
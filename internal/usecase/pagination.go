@@ -0,0 +1,26 @@
+package usecase
+
+import (
+	"fmt"
+	"tages-task-go/pkg/errs"
+)
+
+const (
+	// defaultPageSize - размер страницы, если клиент не указал limit
+	defaultPageSize = 20
+	// maxPageSize - максимальный размер страницы
+	maxPageSize = 100
+)
+
+// pageLimit возвращает размер страницы с учетом значения по умолчанию и верхней границы
+func pageLimit(limit int) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, errs.New(errs.ErrValidation, fmt.Sprintf("limit must not be negative, got %d", limit), nil)
+	case limit == 0:
+		return defaultPageSize, nil
+	case limit > maxPageSize:
+		return maxPageSize, nil
+	}
+	return limit, nil
+}
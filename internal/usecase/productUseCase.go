@@ -2,10 +2,15 @@ package usecase
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"strings"
+	"tages-task-go/pkg/errs"
 	"tages-task-go/pkg/logging"
+	"tages-task-go/pkg/models"
+	"tages-task-go/pkg/models/money"
 	"tages-task-go/pkg/models/service"
 	"tages-task-go/pkg/models/usecase"
+	"tages-task-go/pkg/tracing"
 )
 
 //type ProductUseCase interface {
@@ -15,66 +20,182 @@ import (
 //}
 
 type ProductRepository interface {
-	CreateProduct(ctx context.Context, product service.ProductSrv) error
+	CreateProduct(ctx context.Context, product service.ProductSrv) (service.ProductSrv, error)
 	GetProductByID(ctx context.Context, id int) (service.ProductSrv, error)
-	GetAllProducts(ctx context.Context) ([]service.ProductSrv, error)
+	ListProducts(ctx context.Context, filter service.ProductFilterSrv) (service.ProductPageSrv, error)
+	UpdateProduct(ctx context.Context, product service.ProductSrv) (service.ProductSrv, error)
+	AdjustProductStock(ctx context.Context, id, delta int) (service.ProductSrv, error)
+	DeleteProduct(ctx context.Context, id int) error
+	SearchProducts(ctx context.Context, text string, limit int) ([]service.ProductSearchResultSrv, error)
 }
 
+// ErrInvalidPrice возвращается для отрицательной цены или некорректного кода валюты
+var ErrInvalidPrice = errs.New(errs.ErrValidation,
+	"product price must be non-negative with a valid ISO 4217 currency code", nil)
+
+// ErrZeroStockDelta возвращается для изменения остатка на 0
+var ErrZeroStockDelta = errs.New(errs.ErrValidation, "stock delta must not be zero", nil)
+
+// ErrEmptySearchQuery возвращается для поискового запроса без значимых символов
+var ErrEmptySearchQuery = errs.New(errs.ErrValidation, "search query must not be empty", nil)
+
 type productUsecase struct {
-	repo   ProductRepository
-	logger *logging.Logger
+	repo ProductRepository
 }
 
-func NewProductUseCase(repo ProductRepository, logger *logging.Logger) *productUsecase {
-	return &productUsecase{repo: repo, logger: logger}
+func NewProductUseCase(repo ProductRepository) *productUsecase {
+	return &productUsecase{repo: repo}
+}
+
+// normalizePrice подставляет валюту по умолчанию и проверяет корректность цены продукта
+func normalizePrice(price *money.Money) error {
+	if price.Currency == "" {
+		price.Currency = money.DefaultCurrency
+	}
+	if price.Amount.IsNegative() || !money.IsValidCurrency(price.Currency) {
+		return errs.New(errs.ErrValidation, fmt.Sprintf("invalid price %s: %s", price, ErrInvalidPrice), ErrInvalidPrice)
+	}
+	return nil
 }
 
-func (p *productUsecase) CreateProduct(ctx context.Context, product usecase.ProductUC) error {
+func (p *productUsecase) CreateProduct(ctx context.Context, product usecase.ProductUC) (usecase.ProductUC, error) {
+	ctx, span := tracing.Start(ctx, "productUseCase.CreateProduct")
+	defer span.End()
+
+	if err := normalizePrice(&product.Price); err != nil {
+		return usecase.ProductUC{}, err
+	}
+
 	productSrv := service.ProductSrv{
 		Name:  product.Name,
 		Price: product.Price,
+		Stock: product.Stock,
 	}
 
-	if err := p.repo.CreateProduct(ctx, productSrv); err != nil {
-		p.logger.Error("Failed to create product: ", err)
-		return errors.New("failed to create product: " + err.Error())
+	created, err := p.repo.CreateProduct(ctx, productSrv)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to create product: ", err)
+		return usecase.ProductUC{}, fmt.Errorf("failed to create product: %w", err)
 	}
-	p.logger.Info("Product created successfully")
-	return nil
+	logging.FromContext(ctx).Info("Product created successfully with ID:", created.ID)
+	return models.FromServiceToUseCaseProduct(created), nil
 }
 
 func (p *productUsecase) GetProduct(ctx context.Context, id int) (usecase.ProductUC, error) {
+	ctx, span := tracing.Start(ctx, "productUseCase.GetProduct")
+	defer span.End()
+
 	productSrv, err := p.repo.GetProductByID(ctx, id)
 	if err != nil {
-		p.logger.Error("Failed to get product by ID: ", err)
-		return usecase.ProductUC{}, errors.New("failed to get product: " + err.Error())
+		logging.FromContext(ctx).Error("Failed to get product by ID: ", err)
+		return usecase.ProductUC{}, fmt.Errorf("failed to get product: %w", err)
 	}
 
 	productUC := usecase.ProductUC{
 		ID:    productSrv.ID,
 		Name:  productSrv.Name,
 		Price: productSrv.Price,
+		Stock: productSrv.Stock,
 	}
-	p.logger.Info("Product retrieved successfully by ID:", id)
+	logging.FromContext(ctx).Info("Product retrieved successfully by ID:", id)
 	return productUC, nil
 }
 
-func (p *productUsecase) GetAllProducts(ctx context.Context) ([]usecase.ProductUC, error) {
-	productsSrv, err := p.repo.GetAllProducts(ctx)
+// ListProducts возвращает страницу продуктов, отобранных и отсортированных по фильтру
+func (p *productUsecase) ListProducts(ctx context.Context, filter usecase.ProductFilterUC) (usecase.ProductPageUC, error) {
+	ctx, span := tracing.Start(ctx, "productUseCase.ListProducts")
+	defer span.End()
+
+	limit, err := pageLimit(filter.Limit)
+	if err != nil {
+		return usecase.ProductPageUC{}, err
+	}
+	filter.Limit = limit
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Cmp(*filter.MaxPrice) > 0 {
+		return usecase.ProductPageUC{}, errs.New(errs.ErrValidation,
+			fmt.Sprintf("min_price %s is greater than max_price %s", filter.MinPrice, filter.MaxPrice), nil)
+	}
+
+	pageSrv, err := p.repo.ListProducts(ctx, models.FromUseCaseToServiceProductFilter(filter))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to list products: ", err)
+		return usecase.ProductPageUC{}, fmt.Errorf("failed to list products: %w", err)
+	}
+	logging.FromContext(ctx).Infof("Listed %d products", len(pageSrv.Items))
+	return models.FromServiceToUseCaseProductPage(pageSrv), nil
+}
+
+func (p *productUsecase) UpdateProduct(ctx context.Context, product usecase.ProductUC) (usecase.ProductUC, error) {
+	ctx, span := tracing.Start(ctx, "productUseCase.UpdateProduct")
+	defer span.End()
+
+	if err := normalizePrice(&product.Price); err != nil {
+		return usecase.ProductUC{}, err
+	}
+
+	productSrv, err := p.repo.UpdateProduct(ctx, models.FromUseCaseToServiceProduct(product))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to update product: ", err)
+		return usecase.ProductUC{}, fmt.Errorf("failed to update product: %w", err)
+	}
+	logging.FromContext(ctx).Info("Product updated successfully by ID:", product.ID)
+	return models.FromServiceToUseCaseProduct(productSrv), nil
+}
+
+// AdjustProductStock увеличивает остаток продукта на delta или уменьшает, если delta отрицательна
+func (p *productUsecase) AdjustProductStock(ctx context.Context, id, delta int) (usecase.ProductUC, error) {
+	ctx, span := tracing.Start(ctx, "productUseCase.AdjustProductStock")
+	defer span.End()
+
+	if delta == 0 {
+		return usecase.ProductUC{}, ErrZeroStockDelta
+	}
+
+	productSrv, err := p.repo.AdjustProductStock(ctx, id, delta)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to adjust product stock: ", err)
+		return usecase.ProductUC{}, fmt.Errorf("failed to adjust product stock: %w", err)
+	}
+	logging.FromContext(ctx).Infof("Product %d stock adjusted by %d to %d", id, delta, productSrv.Stock)
+	return models.FromServiceToUseCaseProduct(productSrv), nil
+}
+
+func (p *productUsecase) DeleteProduct(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "productUseCase.DeleteProduct")
+	defer span.End()
+
+	if err := p.repo.DeleteProduct(ctx, id); err != nil {
+		logging.FromContext(ctx).Error("Failed to delete product: ", err)
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	logging.FromContext(ctx).Info("Product deleted successfully by ID:", id)
+	return nil
+}
+
+// SearchProducts ищет продукты по названию с учетом опечаток, самые релевантные первыми
+func (p *productUsecase) SearchProducts(ctx context.Context, text string, limit int) ([]usecase.ProductSearchResultUC, error) {
+	ctx, span := tracing.Start(ctx, "productUseCase.SearchProducts")
+	defer span.End()
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	limit, err := pageLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsSrv, err := p.repo.SearchProducts(ctx, text, limit)
 	if err != nil {
-		p.logger.Error("Failed to get all products: ", err)
-		return nil, errors.New("failed to get products: " + err.Error())
+		logging.FromContext(ctx).Error("Failed to search products: ", err)
+		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
 
-	var productsUC []usecase.ProductUC
-	for _, productSrv := range productsSrv {
-		productUC := usecase.ProductUC{
-			ID:    productSrv.ID,
-			Name:  productSrv.Name,
-			Price: productSrv.Price,
-		}
-		productsUC = append(productsUC, productUC)
+	resultsUC := make([]usecase.ProductSearchResultUC, 0, len(resultsSrv))
+	for _, resultSrv := range resultsSrv {
+		resultsUC = append(resultsUC, models.FromServiceToUseCaseProductSearchResult(resultSrv))
 	}
-	p.logger.Info("All products retrieved successfully")
-	return productsUC, nil
+	logging.FromContext(ctx).Infof("Product search %q returned %d results", text, len(resultsUC))
+	return resultsUC, nil
 }
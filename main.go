@@ -8,7 +8,7 @@ import (
 	"sync"
 	"syscall"
 	"tages-task-go/cmd/server"
-	"time"
+	"tages-task-go/internal/config"
 )
 
 func main() {
@@ -17,7 +17,6 @@ func main() {
 	if err != nil {
 		log.Fatalf("Ошибка инициализации приложения: %v", err)
 	}
-	defer server.DbPool.Close()
 
 	// Используем WaitGroup для управления горутинами
 	var wg sync.WaitGroup
@@ -33,15 +32,20 @@ func main() {
 	log.Println("Получен сигнал для завершения")
 
 	// Инициализация контекста для shutdown с таймаутом
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetConfig().Listen.ShutdownTimeout)
 	defer cancel()
 
-	// Завершение работы HTTP-сервера
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Ошибка при завершении сервера: %v", err)
+	// Завершение работы HTTP-сервера. Пул соединений закрывается и при ошибке
+	shutdownErr := server.Shutdown(ctx)
+	if shutdownErr != nil {
+		log.Printf("Ошибка при завершении сервера: %v", shutdownErr)
 	}
 
 	// Ожидание завершения горутины сервера
 	wg.Wait()
+	server.DbPool.Close()
+	if shutdownErr != nil {
+		os.Exit(1)
+	}
 	log.Println("Приложение завершено.")
 }
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"time"
+)
+
+// minHMACSecretLength - минимальная длина секрета HS256 в байтах (RFC 7518, раздел 3.2)
+const minHMACSecretLength = 32
+
+var (
+	// ErrNoSigningKey возвращается, если в конфигурации не задан ни один ключ проверки подписи
+	ErrNoSigningKey = errors.New("jwt: neither hmac_secret nor rsa_public_key_file is configured")
+	// ErrInvalidToken возвращается для токена, который не прошел проверку
+	ErrInvalidToken = errors.New("invalid access token")
+)
+
+// JWTConfig - параметры проверки токенов доступа. Должен быть задан хотя бы один ключ:
+// секрет для HS256 или открытый ключ RSA в формате PEM для RS256
+type JWTConfig struct {
+	HMACSecret       string        `yaml:"hmac_secret" secret:"true"`
+	RSAPublicKeyFile string        `yaml:"rsa_public_key_file"`
+	Issuer           string        `yaml:"issuer"`
+	Audience         string        `yaml:"audience"`
+	Leeway           time.Duration `yaml:"leeway" env-default:"30s"`
+}
+
+// claims - содержимое токена доступа
+type claims struct {
+	Roles      []string `json:"roles"`
+	CustomerID int      `json:"customer_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTVerifier проверяет подпись и срок действия токенов доступа
+type JWTVerifier struct {
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	parser     *jwt.Parser
+}
+
+// Validate проверяет настройки без чтения файла ключа: задан хотя бы один ключ, секрет достаточно длинный
+func (cfg JWTConfig) Validate() error {
+	if cfg.HMACSecret == "" && cfg.RSAPublicKeyFile == "" {
+		return ErrNoSigningKey
+	}
+	if cfg.HMACSecret != "" && len(cfg.HMACSecret) < minHMACSecretLength {
+		return fmt.Errorf("jwt: hmac_secret must be at least %d bytes long", minHMACSecretLength)
+	}
+	if cfg.Leeway < 0 {
+		return fmt.Errorf("jwt: leeway must not be negative")
+	}
+	return nil
+}
+
+// NewJWTVerifier загружает ключи проверки подписи из конфигурации
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	v := &JWTVerifier{}
+	var methods []string
+	if cfg.HMACSecret != "" {
+		v.hmacSecret = []byte(cfg.HMACSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.RSAPublicKeyFile != "" {
+		pemData, err := os.ReadFile(cfg.RSAPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: failed to read rsa_public_key_file: %w", err)
+		}
+		if v.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pemData); err != nil {
+			return nil, fmt.Errorf("jwt: failed to parse rsa_public_key_file: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(options...)
+	return v, nil
+}
+
+// Verify проверяет токен и возвращает субъекта, которому он выдан
+func (v *JWTVerifier) Verify(tokenString string) (Principal, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(tokenString, &c, v.key)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if c.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: sub claim is required", ErrInvalidToken)
+	}
+
+	principal := Principal{Subject: c.Subject, Roles: c.Roles, CustomerID: c.CustomerID}
+	if _, own := principal.OwnCustomerID(); own && principal.CustomerID <= 0 {
+		return Principal{}, fmt.Errorf("%w: customer_id claim is required for the customer role", ErrInvalidToken)
+	}
+	return principal, nil
+}
+
+// key выбирает ключ проверки подписи по алгоритму токена
+func (v *JWTVerifier) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return v.hmacSecret, nil
+	case *jwt.SigningMethodRSA:
+		return v.rsaKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,99 @@
+package auth
+
+import "context"
+
+// Роли, которые выдаются в токенах доступа
+const (
+	// RoleAdmin имеет доступ ко всем операциям
+	RoleAdmin = "admin"
+	// RoleCatalogAdmin управляет каталогом товаров
+	RoleCatalogAdmin = "catalog_admin"
+	// RoleOps обрабатывает заказы: меняет их статусы
+	RoleOps = "ops"
+	// RoleSupport работает с покупателями и просматривает их заказы
+	RoleSupport = "support"
+	// RoleCustomer - покупатель, которому доступны только его собственные данные
+	RoleCustomer = "customer"
+)
+
+// Principal - аутентифицированный субъект запроса: пользователь с ролями из JWT
+// или межсервисный клиент с правами API-ключа (APIKeyID != 0)
+type Principal struct {
+	Subject    string
+	Roles      []string
+	CustomerID int
+	APIKeyID   int
+	Scopes     []string
+}
+
+// Allows сообщает, разрешена ли субъекту операция. Пользователю нужна одна из ролей,
+// API-ключу - право scope. Пустой scope означает, что операция недоступна API-ключам
+func (p Principal) Allows(scope string, roles ...string) bool {
+	if p.APIKeyID != 0 {
+		return scope != "" && p.HasScope(scope)
+	}
+	return p.HasAnyRole(roles...)
+}
+
+// HasScope сообщает, выдано ли API-ключу право scope
+func (p Principal) HasScope(scope string) bool {
+	for _, have := range p.Scopes {
+		if have == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyRole сообщает, есть ли у субъекта хотя бы одна из ролей. Администратору доступно все
+func (p Principal) HasAnyRole(roles ...string) bool {
+	for _, have := range p.Roles {
+		if have == RoleAdmin {
+			return true
+		}
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OwnCustomerID возвращает ID покупателя, если к маршруту с ролями roles субъект допущен только
+// как покупатель, и ему доступны только данные этого покупателя. Роль сотрудника, не дающая доступа
+// к маршруту, не снимает ограничение: catalog_admin с ролью customer видит в заказах только свои
+func (p Principal) OwnCustomerID(roles ...string) (int, bool) {
+	if p.APIKeyID != 0 || p.hasRole(RoleAdmin) || !p.hasRole(RoleCustomer) {
+		return 0, false
+	}
+	for _, role := range roles {
+		if role != RoleCustomer && p.hasRole(role) {
+			return 0, false
+		}
+	}
+	return p.CustomerID, true
+}
+
+// hasRole сообщает, выдана ли субъекту роль role. В отличие от HasAnyRole не учитывает права администратора
+func (p Principal) hasRole(role string) bool {
+	for _, have := range p.Roles {
+		if have == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// NewContext возвращает контекст с субъектом запроса
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext возвращает субъекта запроса, если запрос аутентифицирован
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
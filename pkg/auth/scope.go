@@ -0,0 +1,22 @@
+package auth
+
+// Права API-ключей межсервисных клиентов
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeOrdersRead    = "orders:read"
+	ScopeOrdersWrite   = "orders:write"
+)
+
+// Scopes - все права, которые можно выдать API-ключу
+var Scopes = []string{ScopeProductsRead, ScopeProductsWrite, ScopeOrdersRead, ScopeOrdersWrite}
+
+// IsValidScope сообщает, существует ли право с таким именем
+func IsValidScope(scope string) bool {
+	for _, known := range Scopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Категории ошибок, по которым транспортный слой выбирает HTTP-статус. Их возвращают и репозиторий,
+// и usecase, поэтому транспорт не зависит от хранилища. Проверяются через errors.Is на любой глубине цепочки %w
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrValidation    = errors.New("validation failed")
+	ErrUnprocessable = errors.New("unprocessable request")
+	ErrUnavailable   = errors.New("service unavailable")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrRateLimited   = errors.New("rate limit exceeded")
+)
+
+var (
+	// ErrProductNotFound возвращается, если продукта с указанным ID не существует
+	ErrProductNotFound = New(ErrNotFound, "product not found", nil)
+	// ErrOrderNotFound возвращается, если заказа с указанным ID не существует
+	ErrOrderNotFound = New(ErrNotFound, "order not found", nil)
+	// ErrStatusConflict возвращается, если статус заказа был изменен параллельным запросом
+	ErrStatusConflict = New(ErrConflict, "order status was changed concurrently, retry the request", nil)
+	// ErrProductInUse возвращается при попытке удалить продукт, на который ссылаются заказы
+	ErrProductInUse = New(ErrConflict, "product is referenced by existing orders", nil)
+	// ErrCustomerNotFound возвращается, если покупателя с указанным ID не существует
+	ErrCustomerNotFound = New(ErrNotFound, "customer not found", nil)
+	// ErrCustomerInUse возвращается при попытке удалить покупателя, у которого есть заказы
+	ErrCustomerInUse = New(ErrConflict, "customer has existing orders", nil)
+	// ErrCustomerEmailTaken возвращается, если email уже принадлежит другому покупателю
+	ErrCustomerEmailTaken = New(ErrConflict, "customer with this email already exists", nil)
+	// ErrAPIKeyNotFound возвращается, если API-ключа с указанным ID или префиксом не существует
+	ErrAPIKeyNotFound = New(ErrNotFound, "api key not found", nil)
+)
+
+// Error - доменная ошибка с категорией Kind и безопасным для клиента сообщением Message.
+// Исходная ошибка сохраняется в Err и попадает только в логи
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+// New создает доменную ошибку категории kind
+func New(kind error, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil || e.Err.Error() == e.Message {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	errs := []error{e.Kind}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// InsufficientStockError возвращается, если остатка товара не хватает для оформления заказа
+// или списания
+type InsufficientStockError struct {
+	ProductID int
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %d: requested %d, available %d",
+		e.ProductID, e.Requested, e.Available)
+}
@@ -0,0 +1,18 @@
+package logging
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext возвращает копию ctx, в которой хранится логгер запроса
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext возвращает логгер запроса из ctx, а вне запроса - глобальный логгер
+func FromContext(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return logger
+	}
+	return GetLogger()
+}
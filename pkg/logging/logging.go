@@ -3,24 +3,66 @@ package logging
 import (
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 	"io"
 	"os"
 	"path"
 	"runtime"
+	"sync"
 )
 
+// Форматы записей журнала
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Куда выводится журнал
+const (
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+)
+
+// Config - настройки журнала
+type Config struct {
+	// Level - минимальный уровень записей: trace, debug, info, warn, error, fatal или panic
+	Level string `yaml:"level" env-default:"info"`
+	// Format - text или json
+	Format string `yaml:"format" env-default:"text"`
+	// Output - stdout, file или both
+	Output string `yaml:"output" env-default:"stdout"`
+	// ReportCaller добавляет в запись функцию и строку, из которой она сделана
+	ReportCaller bool       `yaml:"report_caller"`
+	File         FileConfig `yaml:"file"`
+}
+
+// FileConfig - настройки файла журнала и его ротации
+type FileConfig struct {
+	Path string `yaml:"path" env-default:"logs/all.log"`
+	// MaxSizeMB - размер файла, после которого он переименовывается и начинается новый
+	MaxSizeMB int `yaml:"max_size_mb" env-default:"100"`
+	// MaxAgeDays - сколько дней хранятся старые файлы, 0 - без ограничения
+	MaxAgeDays int `yaml:"max_age_days" env-default:"7"`
+	// MaxBackups - сколько старых файлов хранится, 0 - без ограничения
+	MaxBackups int  `yaml:"max_backups" env-default:"5"`
+	Compress   bool `yaml:"compress"`
+}
+
 var loggerInstance *logrus.Logger
 var logger *Logger
+var once sync.Once
+
+// fileOutput - открытый файл журнала, закрывается при смене настроек
+var fileOutput io.Closer
 
 type Logger struct {
 	*logrus.Entry
 }
 
-// GetLogger возвращает экземпляр логгера
+// GetLogger возвращает экземпляр логгера. До вызова Configure журнал пишется в stdout с уровнем info
 func GetLogger() *Logger {
-	if logger == nil {
-		initLogger()
-	}
+	once.Do(initLogger)
 	return logger
 }
 
@@ -31,34 +73,109 @@ func (l *Logger) GetLoggerWithField(key string, value interface{}) *Logger {
 // initLogger инициализирует глобальный логгер
 func initLogger() {
 	l := logrus.New()
-	l.SetReportCaller(true)
-	l.Formatter = &logrus.TextFormatter{
-		CallerPrettyfier: func(f *runtime.Frame) (string, string) {
-			filename := path.Base(f.File)
-			return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
-		},
+	l.SetOutput(os.Stdout)
+	l.SetLevel(logrus.InfoLevel)
+	l.Formatter = textFormatter()
+
+	loggerInstance = l
+	logger = &Logger{logrus.NewEntry(loggerInstance)}
+}
+
+// Validate проверяет настройки журнала
+func (c Config) Validate() error {
+	if _, err := logrus.ParseLevel(c.Level); err != nil {
+		return fmt.Errorf("logging: invalid level %q", c.Level)
+	}
+	if c.Format != FormatText && c.Format != FormatJSON {
+		return fmt.Errorf("logging: invalid format %q, expected text or json", c.Format)
+	}
+	switch c.Output {
+	case OutputStdout:
+	case OutputFile, OutputBoth:
+		if c.File.Path == "" {
+			return fmt.Errorf("logging: file.path is required when output is %s", c.Output)
+		}
+		if c.File.MaxSizeMB < 0 || c.File.MaxAgeDays < 0 || c.File.MaxBackups < 0 {
+			return fmt.Errorf("logging: file rotation limits must not be negative")
+		}
+	default:
+		return fmt.Errorf("logging: invalid output %q, expected stdout, file or both", c.Output)
+	}
+	return nil
+}
+
+// Configure применяет настройки к глобальному логгеру. Логгеры, полученные ранее, тоже начинают
+// писать по новым настройкам, так как разделяют один экземпляр logrus
+func Configure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	GetLogger()
+
+	level, _ := logrus.ParseLevel(cfg.Level)
+	var formatter logrus.Formatter = textFormatter()
+	if cfg.Format == FormatJSON {
+		formatter = &logrus.JSONFormatter{CallerPrettyfier: callerPrettyfier}
+	}
+
+	var file *lumberjack.Logger
+	var output io.Writer
+	if cfg.Output == OutputFile || cfg.Output == OutputBoth {
+		file = &lumberjack.Logger{
+			Filename:   cfg.File.Path,
+			MaxSize:    cfg.File.MaxSizeMB,
+			MaxAge:     cfg.File.MaxAgeDays,
+			MaxBackups: cfg.File.MaxBackups,
+			Compress:   cfg.File.Compress,
+		}
+	}
+	switch cfg.Output {
+	case OutputFile:
+		output = file
+	case OutputBoth:
+		output = io.MultiWriter(file, os.Stdout)
+	default:
+		output = os.Stdout
 	}
 
-	// Создание каталога logs, если он не существует
-	if err := os.MkdirAll("logs", 0755); err != nil {
-		fmt.Printf("Ошибка создания каталога logs: %v\n", err)
+	loggerInstance.SetFormatter(formatter)
+	loggerInstance.SetReportCaller(cfg.ReportCaller)
+	loggerInstance.SetOutput(output)
+	loggerInstance.SetLevel(level)
+
+	if fileOutput != nil {
+		fileOutput.Close()
+		fileOutput = nil
+	}
+	if file != nil {
+		fileOutput = file
 	}
+	return nil
+}
 
-	// Открытие/создание файла для записи логов
-	allFile, err := os.OpenFile("logs/all.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// SetLevel меняет уровень журнала во время работы сервиса
+func SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
 	if err != nil {
-		fmt.Printf("Ошибка открытия файла логов: %v\n", err)
-		return
+		return fmt.Errorf("invalid log level %q: %w", level, err)
 	}
+	GetLogger()
+	loggerInstance.SetLevel(parsed)
+	return nil
+}
 
-	// Настройка вывода логов в файл и консоль
-	multiWriter := io.MultiWriter(allFile, os.Stdout)
-	l.SetOutput(multiWriter)
+// Level возвращает текущий уровень журнала
+func Level() string {
+	GetLogger()
+	return loggerInstance.GetLevel().String()
+}
 
-	// Установка уровня логирования
-	l.SetLevel(logrus.TraceLevel)
+func textFormatter() *logrus.TextFormatter {
+	return &logrus.TextFormatter{CallerPrettyfier: callerPrettyfier}
+}
 
-	loggerInstance = l
-	logger = &Logger{logrus.NewEntry(loggerInstance)}
-	fmt.Println("Логгер инициализирован")
+// callerPrettyfier сокращает путь к файлу вызывающего кода до имени файла
+func callerPrettyfier(f *runtime.Frame) (string, string) {
+	filename := path.Base(f.File)
+	return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
 }
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"tages-task-go/pkg/models/money"
+	"time"
+)
+
+// namespace - общий префикс имен метрик сервиса
+const namespace = "tages"
+
+// registry содержит только метрики сервиса и стандартные метрики процесса и среды Go
+var registry = prometheus.NewRegistry()
+
+var factory = promauto.With(registry)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route template and response status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	dbQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of repository methods by repository and method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+
+	ordersCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "created_total",
+		Help:      "Orders created successfully.",
+	})
+
+	orderRevenue = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "revenue_total",
+		Help:      "Total price of created orders by currency.",
+	}, []string{"currency"})
+
+	orderFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "failed_total",
+		Help:      "Failed order creation attempts by reason.",
+	}, []string{"reason"})
+)
+
+// Handler возвращает обработчик, отдающий метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Register регистрирует дополнительный сборщик метрик, например статистику пула соединений
+func Register(collector prometheus.Collector) error {
+	return registry.Register(collector)
+}
+
+// ObserveHTTPRequest учитывает обработанный HTTP-запрос. route - шаблон маршрута, а не путь запроса,
+// чтобы идентификаторы в пути не порождали новые временные ряды
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveQuery учитывает длительность метода репозитория, начатого в start.
+// Вызывается через defer в начале метода
+func ObserveQuery(repository, method string, start time.Time) {
+	dbQueryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
+
+// OrderCreated учитывает созданный заказ и его сумму
+func OrderCreated(total money.Money) {
+	ordersCreated.Inc()
+	orderRevenue.WithLabelValues(total.Currency).Add(total.Amount.Float64())
+}
+
+// OrderFailed учитывает неудачную попытку создать заказ
+func OrderFailed(reason string) {
+	orderFailures.WithLabelValues(reason).Inc()
+}
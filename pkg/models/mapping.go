@@ -1,35 +1,53 @@
 package models
 
 import (
+	"tages-task-go/pkg/models/money"
 	modelsSrv "tages-task-go/pkg/models/service"
 	modelsDTO "tages-task-go/pkg/models/transport"
 	modelsUC "tages-task-go/pkg/models/usecase"
 )
 
-// FromDtoToUsecase - преобразует транспортную модель OrderDTO в модель usecase.OrderUC
-func FromDtoToUseCaseOrder(orderDTO modelsDTO.OrderDTO) modelsUC.OrderUC {
+// FromDtoToUsecase - преобразует тело запроса OrderCreateDTO в модель usecase.OrderUC
+func FromDtoToUseCaseOrder(orderDTO modelsDTO.OrderCreateDTO) modelsUC.OrderUC {
+	items := make([]modelsUC.OrderItemUC, 0, len(orderDTO.Items))
+	for _, item := range orderDTO.Items {
+		items = append(items, modelsUC.OrderItemUC{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
 	return modelsUC.OrderUC{
-		ID:        orderDTO.ID,
-		ProductID: orderDTO.ProductID,
-		Quantity:  orderDTO.Quantity,
+		CustomerID: orderDTO.CustomerID,
+		Items:      items,
 	}
 }
 
 // FromUsecaseToDto - преобразует модель usecase.OrderUC обратно в транспортную модель OrderDTO для ответа клиенту
 func FromUseCaseToDtoOrder(orderUC modelsUC.OrderUC) modelsDTO.OrderDTO {
+	items := make([]modelsDTO.OrderItemDTO, 0, len(orderUC.Items))
+	for _, item := range orderUC.Items {
+		items = append(items, modelsDTO.OrderItemDTO{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		})
+	}
 	return modelsDTO.OrderDTO{
-		ID:        orderUC.ID,
-		ProductID: orderUC.ProductID,
-		Quantity:  orderUC.Quantity,
+		ID:         orderUC.ID,
+		CustomerID: orderUC.CustomerID,
+		Status:     string(orderUC.Status),
+		Items:      items,
+		TotalPrice: orderUC.TotalPrice,
+		CreatedAt:  orderUC.CreatedAt,
 	}
 }
 
-// MapToUsecaseProduct - преобразует транспортную модель ProductDTO в usecase.ProductUC
-func FromDtoToUseCaseProduct(productDTO modelsDTO.ProductDTO) modelsUC.ProductUC {
+// MapToUsecaseProduct - преобразует тело запроса ProductCreateDTO в usecase.ProductUC
+func FromDtoToUseCaseProduct(productDTO modelsDTO.ProductCreateDTO) modelsUC.ProductUC {
 	return modelsUC.ProductUC{
-		ID:    productDTO.ID,
 		Name:  productDTO.Name,
 		Price: productDTO.Price,
+		Stock: productDTO.Stock,
 	}
 }
 
@@ -39,15 +57,43 @@ func FromUseCaseToDtoProduct(productUC modelsUC.ProductUC) modelsDTO.ProductDTO
 		ID:    productUC.ID,
 		Name:  productUC.Name,
 		Price: productUC.Price,
+		Stock: productUC.Stock,
+	}
+}
+
+// FromDtoToUseCaseProductUpdate - преобразует тело PUT и PATCH /products/{id} в usecase.ProductUC
+func FromDtoToUseCaseProductUpdate(updateDTO modelsDTO.ProductUpdateDTO) modelsUC.ProductUC {
+	return modelsUC.ProductUC{
+		Name:  updateDTO.Name,
+		Price: updateDTO.Price,
+	}
+}
+
+// FromUseCaseToDtoProductUpdate - возвращает изменяемые через PUT и PATCH поля продукта usecase.ProductUC
+func FromUseCaseToDtoProductUpdate(productUC modelsUC.ProductUC) modelsDTO.ProductUpdateDTO {
+	return modelsDTO.ProductUpdateDTO{
+		Name:  productUC.Name,
+		Price: productUC.Price,
 	}
 }
 
 // FromDtoToUsecase - преобразует транспортную модель OrderDTO в модель usecase.OrderUC
 func FromServiceToUseCaseOrder(orderSrv modelsSrv.OrderSrv) modelsUC.OrderUC {
+	items := make([]modelsUC.OrderItemUC, 0, len(orderSrv.Items))
+	for _, item := range orderSrv.Items {
+		items = append(items, modelsUC.OrderItemUC{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		})
+	}
 	return modelsUC.OrderUC{
-		ID:        orderSrv.ID,
-		ProductID: orderSrv.ProductID,
-		Quantity:  orderSrv.Quantity,
+		ID:         orderSrv.ID,
+		CustomerID: orderSrv.CustomerID,
+		Status:     modelsUC.OrderStatus(orderSrv.Status),
+		Items:      items,
+		TotalPrice: orderSrv.TotalPrice,
+		CreatedAt:  orderSrv.CreatedAt,
 	}
 }
 
@@ -57,16 +103,25 @@ func FromServiceToUseCaseProduct(productSrv modelsSrv.ProductSrv) modelsUC.Produ
 		ID:    productSrv.ID,
 		Name:  productSrv.Name,
 		Price: productSrv.Price,
+		Stock: productSrv.Stock,
 	}
 }
 
 // FromUsecaseToDto - преобразует модель usecase.OrderUC обратно в транспортную модель OrderDTO для ответа клиенту
 func FromUseCaseToServiceOrder(orderUC modelsUC.OrderUC) modelsSrv.OrderSrv {
+	items := make([]modelsSrv.OrderItemSrv, 0, len(orderUC.Items))
+	for _, item := range orderUC.Items {
+		items = append(items, modelsSrv.OrderItemSrv{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
 	return modelsSrv.OrderSrv{
 		ID:         orderUC.ID,
-		ProductID:  orderUC.ProductID,
-		Quantity:   orderUC.Quantity,
-		TotalPrice: 0,
+		CustomerID: orderUC.CustomerID,
+		Status:     string(orderUC.Status),
+		Items:      items,
+		TotalPrice: money.Money{},
 	}
 }
 
@@ -76,5 +131,339 @@ func FromUseCaseToServiceProduct(productUC modelsUC.ProductUC) modelsSrv.Product
 		ID:    productUC.ID,
 		Name:  productUC.Name,
 		Price: productUC.Price,
+		Stock: productUC.Stock,
+	}
+}
+
+// FromDtoToUseCaseOrderTransition - преобразует тело запроса OrderTransitionCreateDTO в usecase.OrderTransitionUC
+func FromDtoToUseCaseOrderTransition(transitionDTO modelsDTO.OrderTransitionCreateDTO) modelsUC.OrderTransitionUC {
+	return modelsUC.OrderTransitionUC{
+		To: modelsUC.OrderStatus(transitionDTO.To),
+	}
+}
+
+// FromUseCaseToDtoOrderTransition - преобразует модель usecase.OrderTransitionUC в транспортную модель OrderTransitionDTO
+func FromUseCaseToDtoOrderTransition(transitionUC modelsUC.OrderTransitionUC) modelsDTO.OrderTransitionDTO {
+	return modelsDTO.OrderTransitionDTO{
+		ID:        transitionUC.ID,
+		OrderID:   transitionUC.OrderID,
+		From:      string(transitionUC.From),
+		To:        string(transitionUC.To),
+		ChangedBy: transitionUC.ChangedBy,
+		ChangedAt: transitionUC.ChangedAt,
+	}
+}
+
+// FromServiceToUseCaseOrderTransition - преобразует модель service.OrderTransitionSrv в usecase.OrderTransitionUC
+func FromServiceToUseCaseOrderTransition(transitionSrv modelsSrv.OrderTransitionSrv) modelsUC.OrderTransitionUC {
+	return modelsUC.OrderTransitionUC{
+		ID:        transitionSrv.ID,
+		OrderID:   transitionSrv.OrderID,
+		From:      modelsUC.OrderStatus(transitionSrv.FromStatus),
+		To:        modelsUC.OrderStatus(transitionSrv.ToStatus),
+		ChangedBy: transitionSrv.ChangedBy,
+		ChangedAt: transitionSrv.ChangedAt,
+	}
+}
+
+// FromUseCaseToServiceOrderTransition - преобразует модель usecase.OrderTransitionUC в service.OrderTransitionSrv
+func FromUseCaseToServiceOrderTransition(transitionUC modelsUC.OrderTransitionUC) modelsSrv.OrderTransitionSrv {
+	return modelsSrv.OrderTransitionSrv{
+		ID:         transitionUC.ID,
+		OrderID:    transitionUC.OrderID,
+		FromStatus: string(transitionUC.From),
+		ToStatus:   string(transitionUC.To),
+		ChangedBy:  transitionUC.ChangedBy,
+		ChangedAt:  transitionUC.ChangedAt,
+	}
+}
+
+// FromDtoToUseCaseProductFilter - преобразует параметры запроса ProductFilterDTO в usecase.ProductFilterUC
+func FromDtoToUseCaseProductFilter(filterDTO modelsDTO.ProductFilterDTO) modelsUC.ProductFilterUC {
+	return modelsUC.ProductFilterUC{
+		MinPrice:     filterDTO.MinPrice,
+		MaxPrice:     filterDTO.MaxPrice,
+		NameContains: filterDTO.NameContains,
+		Sort:         filterDTO.Sort,
+		Limit:        filterDTO.Limit,
+		Cursor:       filterDTO.Cursor,
+	}
+}
+
+// FromUseCaseToServiceProductFilter - преобразует usecase.ProductFilterUC в service.ProductFilterSrv
+func FromUseCaseToServiceProductFilter(filterUC modelsUC.ProductFilterUC) modelsSrv.ProductFilterSrv {
+	return modelsSrv.ProductFilterSrv{
+		MinPrice:     filterUC.MinPrice,
+		MaxPrice:     filterUC.MaxPrice,
+		NameContains: filterUC.NameContains,
+		Sort:         filterUC.Sort,
+		Limit:        filterUC.Limit,
+		Cursor:       filterUC.Cursor,
+	}
+}
+
+// FromServiceToUseCaseProductPage - преобразует страницу service.ProductPageSrv в usecase.ProductPageUC
+func FromServiceToUseCaseProductPage(pageSrv modelsSrv.ProductPageSrv) modelsUC.ProductPageUC {
+	items := make([]modelsUC.ProductUC, 0, len(pageSrv.Items))
+	for _, productSrv := range pageSrv.Items {
+		items = append(items, FromServiceToUseCaseProduct(productSrv))
+	}
+	return modelsUC.ProductPageUC{
+		Items:      items,
+		NextCursor: pageSrv.NextCursor,
+	}
+}
+
+// FromUseCaseToDtoProductPage - преобразует страницу usecase.ProductPageUC в транспортную модель ProductPageDTO
+func FromUseCaseToDtoProductPage(pageUC modelsUC.ProductPageUC) modelsDTO.ProductPageDTO {
+	items := make([]modelsDTO.ProductDTO, 0, len(pageUC.Items))
+	for _, productUC := range pageUC.Items {
+		items = append(items, FromUseCaseToDtoProduct(productUC))
+	}
+	return modelsDTO.ProductPageDTO{
+		Items:      items,
+		NextCursor: pageUC.NextCursor,
+	}
+}
+
+// FromDtoToUseCaseOrderFilter - преобразует параметры запроса OrderFilterDTO в usecase.OrderFilterUC
+func FromDtoToUseCaseOrderFilter(filterDTO modelsDTO.OrderFilterDTO) modelsUC.OrderFilterUC {
+	return modelsUC.OrderFilterUC{
+		CustomerID:  filterDTO.CustomerID,
+		ProductID:   filterDTO.ProductID,
+		Status:      modelsUC.OrderStatus(filterDTO.Status),
+		CreatedFrom: filterDTO.CreatedFrom,
+		CreatedTo:   filterDTO.CreatedTo,
+		Sort:        filterDTO.Sort,
+		Limit:       filterDTO.Limit,
+		Cursor:      filterDTO.Cursor,
+	}
+}
+
+// FromUseCaseToServiceOrderFilter - преобразует usecase.OrderFilterUC в service.OrderFilterSrv
+func FromUseCaseToServiceOrderFilter(filterUC modelsUC.OrderFilterUC) modelsSrv.OrderFilterSrv {
+	return modelsSrv.OrderFilterSrv{
+		CustomerID:  filterUC.CustomerID,
+		ProductID:   filterUC.ProductID,
+		Status:      string(filterUC.Status),
+		CreatedFrom: filterUC.CreatedFrom,
+		CreatedTo:   filterUC.CreatedTo,
+		Sort:        filterUC.Sort,
+		Limit:       filterUC.Limit,
+		Cursor:      filterUC.Cursor,
+	}
+}
+
+// FromServiceToUseCaseOrderPage - преобразует страницу service.OrderPageSrv в usecase.OrderPageUC
+func FromServiceToUseCaseOrderPage(pageSrv modelsSrv.OrderPageSrv) modelsUC.OrderPageUC {
+	items := make([]modelsUC.OrderUC, 0, len(pageSrv.Items))
+	for _, orderSrv := range pageSrv.Items {
+		items = append(items, FromServiceToUseCaseOrder(*orderSrv))
+	}
+	return modelsUC.OrderPageUC{
+		Items:      items,
+		NextCursor: pageSrv.NextCursor,
+	}
+}
+
+// FromUseCaseToDtoOrderPage - преобразует страницу usecase.OrderPageUC в транспортную модель OrderPageDTO
+func FromUseCaseToDtoOrderPage(pageUC modelsUC.OrderPageUC) modelsDTO.OrderPageDTO {
+	items := make([]modelsDTO.OrderDTO, 0, len(pageUC.Items))
+	for _, orderUC := range pageUC.Items {
+		items = append(items, FromUseCaseToDtoOrder(orderUC))
+	}
+	return modelsDTO.OrderPageDTO{
+		Items:      items,
+		NextCursor: pageUC.NextCursor,
+	}
+}
+
+// FromServiceToUseCaseProductSearchResult - преобразует service.ProductSearchResultSrv в usecase.ProductSearchResultUC
+func FromServiceToUseCaseProductSearchResult(resultSrv modelsSrv.ProductSearchResultSrv) modelsUC.ProductSearchResultUC {
+	return modelsUC.ProductSearchResultUC{
+		Product:   FromServiceToUseCaseProduct(resultSrv.Product),
+		Rank:      resultSrv.Rank,
+		Highlight: resultSrv.Highlight,
+	}
+}
+
+// FromUseCaseToDtoProductSearchResult - преобразует usecase.ProductSearchResultUC в транспортную модель ProductSearchResultDTO
+func FromUseCaseToDtoProductSearchResult(resultUC modelsUC.ProductSearchResultUC) modelsDTO.ProductSearchResultDTO {
+	return modelsDTO.ProductSearchResultDTO{
+		Product:   FromUseCaseToDtoProduct(resultUC.Product),
+		Rank:      resultUC.Rank,
+		Highlight: resultUC.Highlight,
+	}
+}
+
+// FromUseCaseToServiceIdempotencyKey - собирает service.IdempotencyKeySrv из запроса и ответа на него
+func FromUseCaseToServiceIdempotencyKey(requestUC modelsUC.IdempotentRequestUC, responseUC modelsUC.IdempotentResponseUC) modelsSrv.IdempotencyKeySrv {
+	return modelsSrv.IdempotencyKeySrv{
+		Scope:           requestUC.Scope,
+		Key:             requestUC.Key,
+		RequestHash:     requestUC.RequestHash,
+		StatusCode:      responseUC.StatusCode,
+		ResponseHeaders: responseUC.Headers,
+		ResponseBody:    responseUC.Body,
+	}
+}
+
+// FromServiceToUseCaseIdempotentResponse - извлекает сохраненный ответ из service.IdempotencyKeySrv
+func FromServiceToUseCaseIdempotentResponse(keySrv modelsSrv.IdempotencyKeySrv) modelsUC.IdempotentResponseUC {
+	return modelsUC.IdempotentResponseUC{
+		StatusCode: keySrv.StatusCode,
+		Headers:    keySrv.ResponseHeaders,
+		Body:       keySrv.ResponseBody,
+	}
+}
+
+// FromDtoToUseCaseCustomer - преобразует тело запроса CustomerInputDTO в usecase.CustomerUC
+func FromDtoToUseCaseCustomer(customerDTO modelsDTO.CustomerInputDTO) modelsUC.CustomerUC {
+	return modelsUC.CustomerUC{
+		Name:  customerDTO.Name,
+		Email: customerDTO.Email,
+		Phone: customerDTO.Phone,
+	}
+}
+
+// FromUseCaseToDtoCustomerInput - возвращает изменяемые клиентом поля покупателя usecase.CustomerUC
+func FromUseCaseToDtoCustomerInput(customerUC modelsUC.CustomerUC) modelsDTO.CustomerInputDTO {
+	return modelsDTO.CustomerInputDTO{
+		Name:  customerUC.Name,
+		Email: customerUC.Email,
+		Phone: customerUC.Phone,
+	}
+}
+
+// FromUseCaseToDtoCustomer - преобразует модель usecase.CustomerUC в транспортную модель CustomerDTO
+func FromUseCaseToDtoCustomer(customerUC modelsUC.CustomerUC) modelsDTO.CustomerDTO {
+	return modelsDTO.CustomerDTO{
+		ID:        customerUC.ID,
+		Name:      customerUC.Name,
+		Email:     customerUC.Email,
+		Phone:     customerUC.Phone,
+		CreatedAt: customerUC.CreatedAt,
+	}
+}
+
+// FromServiceToUseCaseCustomer - преобразует модель service.CustomerSrv в usecase.CustomerUC
+func FromServiceToUseCaseCustomer(customerSrv modelsSrv.CustomerSrv) modelsUC.CustomerUC {
+	return modelsUC.CustomerUC{
+		ID:        customerSrv.ID,
+		Name:      customerSrv.Name,
+		Email:     customerSrv.Email,
+		Phone:     customerSrv.Phone,
+		CreatedAt: customerSrv.CreatedAt,
+	}
+}
+
+// FromUseCaseToServiceCustomer - преобразует модель usecase.CustomerUC в service.CustomerSrv
+func FromUseCaseToServiceCustomer(customerUC modelsUC.CustomerUC) modelsSrv.CustomerSrv {
+	return modelsSrv.CustomerSrv{
+		ID:    customerUC.ID,
+		Name:  customerUC.Name,
+		Email: customerUC.Email,
+		Phone: customerUC.Phone,
+	}
+}
+
+// FromDtoToUseCaseCustomerFilter - преобразует параметры запроса CustomerFilterDTO в usecase.CustomerFilterUC
+func FromDtoToUseCaseCustomerFilter(filterDTO modelsDTO.CustomerFilterDTO) modelsUC.CustomerFilterUC {
+	return modelsUC.CustomerFilterUC{
+		NameContains: filterDTO.NameContains,
+		Email:        filterDTO.Email,
+		Sort:         filterDTO.Sort,
+		Limit:        filterDTO.Limit,
+		Cursor:       filterDTO.Cursor,
+	}
+}
+
+// FromUseCaseToServiceCustomerFilter - преобразует usecase.CustomerFilterUC в service.CustomerFilterSrv
+func FromUseCaseToServiceCustomerFilter(filterUC modelsUC.CustomerFilterUC) modelsSrv.CustomerFilterSrv {
+	return modelsSrv.CustomerFilterSrv{
+		NameContains: filterUC.NameContains,
+		Email:        filterUC.Email,
+		Sort:         filterUC.Sort,
+		Limit:        filterUC.Limit,
+		Cursor:       filterUC.Cursor,
+	}
+}
+
+// FromServiceToUseCaseCustomerPage - преобразует страницу service.CustomerPageSrv в usecase.CustomerPageUC
+func FromServiceToUseCaseCustomerPage(pageSrv modelsSrv.CustomerPageSrv) modelsUC.CustomerPageUC {
+	items := make([]modelsUC.CustomerUC, 0, len(pageSrv.Items))
+	for _, customerSrv := range pageSrv.Items {
+		items = append(items, FromServiceToUseCaseCustomer(customerSrv))
+	}
+	return modelsUC.CustomerPageUC{
+		Items:      items,
+		NextCursor: pageSrv.NextCursor,
+	}
+}
+
+// FromUseCaseToDtoCustomerPage - преобразует страницу usecase.CustomerPageUC в транспортную модель CustomerPageDTO
+func FromUseCaseToDtoCustomerPage(pageUC modelsUC.CustomerPageUC) modelsDTO.CustomerPageDTO {
+	items := make([]modelsDTO.CustomerDTO, 0, len(pageUC.Items))
+	for _, customerUC := range pageUC.Items {
+		items = append(items, FromUseCaseToDtoCustomer(customerUC))
+	}
+	return modelsDTO.CustomerPageDTO{
+		Items:      items,
+		NextCursor: pageUC.NextCursor,
+	}
+}
+
+// FromDtoToUseCaseAPIKey - преобразует тело запроса APIKeyInputDTO в usecase.APIKeyUC
+func FromDtoToUseCaseAPIKey(keyDTO modelsDTO.APIKeyInputDTO) modelsUC.APIKeyUC {
+	return modelsUC.APIKeyUC{
+		Name:      keyDTO.Name,
+		Scopes:    keyDTO.Scopes,
+		ExpiresAt: keyDTO.ExpiresAt,
+	}
+}
+
+// FromUseCaseToDtoAPIKey - преобразует модель usecase.APIKeyUC в транспортную модель APIKeyDTO
+func FromUseCaseToDtoAPIKey(keyUC modelsUC.APIKeyUC) modelsDTO.APIKeyDTO {
+	return modelsDTO.APIKeyDTO{
+		ID:         keyUC.ID,
+		Name:       keyUC.Name,
+		Prefix:     keyUC.Prefix,
+		Key:        keyUC.Key,
+		Scopes:     keyUC.Scopes,
+		ExpiresAt:  keyUC.ExpiresAt,
+		LastUsedAt: keyUC.LastUsedAt,
+		RevokedAt:  keyUC.RevokedAt,
+		CreatedBy:  keyUC.CreatedBy,
+		CreatedAt:  keyUC.CreatedAt,
+	}
+}
+
+// FromServiceToUseCaseAPIKey - преобразует модель service.APIKeySrv в usecase.APIKeyUC, хэш секрета не переносится
+func FromServiceToUseCaseAPIKey(keySrv modelsSrv.APIKeySrv) modelsUC.APIKeyUC {
+	return modelsUC.APIKeyUC{
+		ID:         keySrv.ID,
+		Name:       keySrv.Name,
+		Prefix:     keySrv.Prefix,
+		Scopes:     keySrv.Scopes,
+		ExpiresAt:  keySrv.ExpiresAt,
+		LastUsedAt: keySrv.LastUsedAt,
+		RevokedAt:  keySrv.RevokedAt,
+		CreatedBy:  keySrv.CreatedBy,
+		CreatedAt:  keySrv.CreatedAt,
+	}
+}
+
+// FromUseCaseToDtoHealthReport - преобразует модель usecase.HealthReportUC в транспортную модель HealthReportDTO
+func FromUseCaseToDtoHealthReport(reportUC modelsUC.HealthReportUC) modelsDTO.HealthReportDTO {
+	components := make(map[string]modelsDTO.ComponentHealthDTO, len(reportUC.Components))
+	for name, component := range reportUC.Components {
+		components[name] = modelsDTO.ComponentHealthDTO{
+			Status:     string(component.Status),
+			DurationMs: component.Duration.Milliseconds(),
+		}
+	}
+	return modelsDTO.HealthReportDTO{
+		Status:     string(reportUC.Status),
+		Components: components,
 	}
 }
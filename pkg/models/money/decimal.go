@@ -0,0 +1,219 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale - количество знаков после запятой, соответствует колонкам NUMERIC(10, 2)
+const Scale = 2
+
+const scaleFactor = 100
+
+var (
+	// ErrInvalidDecimal возвращается при разборе строки, не являющейся десятичным числом
+	ErrInvalidDecimal = errors.New("invalid decimal value")
+	// ErrTooPrecise возвращается, если у числа больше знаков после запятой, чем Scale
+	ErrTooPrecise = errors.New("decimal value has too many fractional digits")
+	// ErrOverflow возвращается при переполнении в арифметике над суммами
+	ErrOverflow = errors.New("decimal value overflow")
+)
+
+// Decimal - точное десятичное число с фиксированными двумя знаками после запятой.
+// Хранится как целое количество сотых (копеек), поэтому ни разбор, ни арифметика,
+// ни сериализация не проходят через float64
+type Decimal struct {
+	minor int64
+}
+
+// NewFromMinor создает Decimal из количества сотых: NewFromMinor(1050) == 10.50
+func NewFromMinor(minor int64) Decimal {
+	return Decimal{minor: minor}
+}
+
+// ParseDecimal разбирает строку вида "-123.45". Незначащие нули после Scale допускаются,
+// значащие цифры после Scale считаются ошибкой
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(str, "-"):
+		negative = true
+		str = str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if len(fracPart) > Scale {
+		if strings.Trim(fracPart[Scale:], "0") != "" {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrTooPrecise, s)
+		}
+		fracPart = fracPart[:Scale]
+	}
+	fracPart += strings.Repeat("0", Scale-len(fracPart))
+
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || units > math.MaxInt64/scaleFactor {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	frac, _ := strconv.ParseInt(fracPart, 10, 64)
+	minor := units*scaleFactor + frac
+	if minor < 0 {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Decimal{minor: minor}, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor возвращает значение в сотых
+func (d Decimal) Minor() int64 {
+	return d.minor
+}
+
+// Float64 возвращает приближенное значение для метрик и других мест, где точность не важна.
+// Для расчетов используйте Add и Mul
+func (d Decimal) Float64() float64 {
+	return float64(d.minor) / scaleFactor
+}
+
+// IsZero сообщает, равно ли значение нулю
+func (d Decimal) IsZero() bool {
+	return d.minor == 0
+}
+
+// IsNegative сообщает, меньше ли значение нуля
+func (d Decimal) IsNegative() bool {
+	return d.minor < 0
+}
+
+// Cmp сравнивает два значения: -1 если d < other, 0 если равны, 1 если d > other
+func (d Decimal) Cmp(other Decimal) int {
+	switch {
+	case d.minor < other.minor:
+		return -1
+	case d.minor > other.minor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Add возвращает сумму d + other
+func (d Decimal) Add(other Decimal) (Decimal, error) {
+	sum := d.minor + other.minor
+	if (other.minor > 0 && sum < d.minor) || (other.minor < 0 && sum > d.minor) {
+		return Decimal{}, ErrOverflow
+	}
+	return Decimal{minor: sum}, nil
+}
+
+// Mul возвращает произведение d на целое число, например цену на количество
+func (d Decimal) Mul(n int64) (Decimal, error) {
+	if d.minor == 0 || n == 0 {
+		return Decimal{}, nil
+	}
+	product := d.minor * n
+	if product/n != d.minor || (d.minor == -1 && n == math.MinInt64) || (n == -1 && d.minor == math.MinInt64) {
+		return Decimal{}, ErrOverflow
+	}
+	return Decimal{minor: product}, nil
+}
+
+// String возвращает значение ровно с двумя знаками после запятой: "10.50", "-0.05"
+func (d Decimal) String() string {
+	minor := d.minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	units := minor / scaleFactor
+	frac := minor % scaleFactor
+	if units < 0 {
+		units = -units
+	}
+	if frac < 0 {
+		frac = -frac
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units, frac)
+}
+
+// MarshalJSON кодирует значение JSON-строкой, чтобы клиенты не разбирали его как float
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON принимает как строку "10.50", так и числовой литерал 10.50.
+// Числовой литерал разбирается по своему тексту, без преобразования в float64
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	str := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(str, "eE") {
+		return fmt.Errorf("%w: exponent notation is not supported: %s", ErrInvalidDecimal, str)
+	}
+	parsed, err := ParseDecimal(str)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan реализует sql.Scanner. pgx передает значения NUMERIC строкой в точном десятичном виде
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		parsed, err := ParseDecimal(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case []byte:
+		return d.Scan(string(v))
+	case int64:
+		return d.Scan(strconv.FormatInt(v, 10))
+	case nil:
+		return fmt.Errorf("%w: cannot scan NULL", ErrInvalidDecimal)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidDecimal, src)
+	}
+	return nil
+}
+
+// Value реализует driver.Valuer, передавая значение в базу строкой
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
@@ -0,0 +1,220 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr error
+	}{
+		{name: "integer", input: "10", want: 1000},
+		{name: "two fractional digits", input: "10.50", want: 1050},
+		{name: "one fractional digit", input: "10.5", want: 1050},
+		{name: "no integer part", input: ".05", want: 5},
+		{name: "no fractional part", input: "7.", want: 700},
+		{name: "negative", input: "-0.05", want: -5},
+		{name: "explicit plus", input: "+1.25", want: 125},
+		{name: "surrounding spaces", input: " 3.10 ", want: 310},
+		{name: "insignificant trailing zeros", input: "1.2500", want: 125},
+		{name: "max value", input: "92233720368547758.07", want: math.MaxInt64},
+		{name: "min representable value", input: "-92233720368547758.07", want: -math.MaxInt64},
+		{name: "too precise", input: "1.005", wantErr: ErrTooPrecise},
+		{name: "empty", input: "", wantErr: ErrInvalidDecimal},
+		{name: "only sign", input: "-", wantErr: ErrInvalidDecimal},
+		{name: "only dot", input: ".", wantErr: ErrInvalidDecimal},
+		{name: "letters", input: "1a.00", wantErr: ErrInvalidDecimal},
+		{name: "double sign", input: "--1", wantErr: ErrInvalidDecimal},
+		{name: "two dots", input: "1.2.3", wantErr: ErrInvalidDecimal},
+		{name: "exponent", input: "1e3", wantErr: ErrInvalidDecimal},
+		{name: "fraction overflows", input: "92233720368547758.08", wantErr: ErrOverflow},
+		{name: "integer part overflows", input: "92233720368547759", wantErr: ErrOverflow},
+		{name: "beyond int64", input: "99999999999999999999999", wantErr: ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDecimal(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseDecimal(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDecimal(%q) unexpected error: %v", tt.input, err)
+			}
+			if got.Minor() != tt.want {
+				t.Errorf("ParseDecimal(%q) = %d, want %d", tt.input, got.Minor(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		minor int64
+		want  string
+	}{
+		{minor: 0, want: "0.00"},
+		{minor: 5, want: "0.05"},
+		{minor: -5, want: "-0.05"},
+		{minor: 1050, want: "10.50"},
+		{minor: -1050, want: "-10.50"},
+		{minor: math.MaxInt64, want: "92233720368547758.07"},
+		{minor: math.MinInt64, want: "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := NewFromMinor(tt.minor).String(); got != tt.want {
+			t.Errorf("NewFromMinor(%d).String() = %q, want %q", tt.minor, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalFloat64(t *testing.T) {
+	tests := []struct {
+		minor int64
+		want  float64
+	}{
+		{minor: 0, want: 0},
+		{minor: 1050, want: 10.5},
+		{minor: -5, want: -0.05},
+		{minor: 199999, want: 1999.99},
+	}
+	for _, tt := range tests {
+		if got := NewFromMinor(tt.minor).Float64(); got != tt.want {
+			t.Errorf("NewFromMinor(%d).Float64() = %v, want %v", tt.minor, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    int64
+		want    int64
+		wantErr bool
+	}{
+		{name: "positive", a: 150, b: 250, want: 400},
+		{name: "negative", a: -150, b: 50, want: -100},
+		{name: "up to max", a: math.MaxInt64 - 1, b: 1, want: math.MaxInt64},
+		{name: "positive overflow", a: math.MaxInt64, b: 1, wantErr: true},
+		{name: "negative overflow", a: math.MinInt64, b: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFromMinor(tt.a).Add(NewFromMinor(tt.b))
+			if tt.wantErr {
+				if !errors.Is(err, ErrOverflow) {
+					t.Fatalf("Add error = %v, want ErrOverflow", err)
+				}
+				return
+			}
+			if err != nil || got.Minor() != tt.want {
+				t.Errorf("Add = %d, %v, want %d", got.Minor(), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalMul(t *testing.T) {
+	tests := []struct {
+		name    string
+		d, n    int64
+		want    int64
+		wantErr bool
+	}{
+		{name: "price by quantity", d: 1050, n: 3, want: 3150},
+		{name: "zero quantity", d: 1050, n: 0, want: 0},
+		{name: "zero price", d: 0, n: math.MaxInt64, want: 0},
+		{name: "negative", d: -25, n: 4, want: -100},
+		{name: "overflow", d: math.MaxInt64 / 2, n: 3, wantErr: true},
+		{name: "min by minus one", d: math.MinInt64, n: -1, wantErr: true},
+		{name: "minus one by min", d: -1, n: math.MinInt64, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFromMinor(tt.d).Mul(tt.n)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOverflow) {
+					t.Fatalf("Mul error = %v, want ErrOverflow", err)
+				}
+				return
+			}
+			if err != nil || got.Minor() != tt.want {
+				t.Errorf("Mul = %d, %v, want %d", got.Minor(), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "string", input: `"10.50"`, want: 1050},
+		{name: "number literal", input: `10.5`, want: 1050},
+		{name: "null keeps value", input: `null`, want: 777},
+		{name: "exponent", input: `1e2`, wantErr: true},
+		{name: "too precise string", input: `"0.001"`, wantErr: true},
+		{name: "not a number", input: `"abc"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewFromMinor(777)
+			err := json.Unmarshal([]byte(tt.input), &d)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %s, want error", tt.input, d)
+				}
+				return
+			}
+			if err != nil || d.Minor() != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, %v, want %d", tt.input, d.Minor(), err, tt.want)
+			}
+		})
+	}
+
+	data, err := json.Marshal(NewFromMinor(-5))
+	if err != nil || string(data) != `"-0.05"` {
+		t.Errorf("Marshal = %s, %v, want \"-0.05\"", data, err)
+	}
+}
+
+func TestDecimalScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    int64
+		wantErr bool
+	}{
+		{name: "string", src: "12.34", want: 1234},
+		{name: "bytes", src: []byte("0.10"), want: 10},
+		{name: "int64", src: int64(7), want: 700},
+		{name: "null", src: nil, wantErr: true},
+		{name: "float", src: 1.5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Decimal
+			err := d.Scan(tt.src)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDecimal) {
+					t.Fatalf("Scan(%v) error = %v, want ErrInvalidDecimal", tt.src, err)
+				}
+				return
+			}
+			if err != nil || d.Minor() != tt.want {
+				t.Errorf("Scan(%v) = %d, %v, want %d", tt.src, d.Minor(), err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultCurrency - валюта, в которой хранятся цены, если она не указана явно
+const DefaultCurrency = "RUB"
+
+// ErrCurrencyMismatch возвращается при арифметике над суммами в разных валютах
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money - денежная сумма в конкретной валюте (код ISO 4217)
+type Money struct {
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// New создает сумму в указанной валюте
+func New(amount Decimal, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero возвращает нулевую сумму в указанной валюте
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// IsValidCurrency проверяет, что код валюты состоит из трех заглавных латинских букв
+func IsValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add возвращает сумму m + other. Складывать можно только суммы в одной валюте
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	amount, err := m.Amount.Add(other.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// Mul возвращает сумму, умноженную на количество
+func (m Money) Mul(quantity int) (Money, error) {
+	amount, err := m.Amount.Mul(int64(quantity))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// String возвращает сумму вместе с валютой: "10.50 RUB"
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
@@ -0,0 +1,16 @@
+package service
+
+import "time"
+
+type APIKeySrv struct {
+	ID         int
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedBy  string
+	CreatedAt  time.Time
+}
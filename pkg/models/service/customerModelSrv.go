@@ -0,0 +1,26 @@
+package service
+
+import "time"
+
+type CustomerSrv struct {
+	ID        int
+	Name      string
+	Email     string
+	Phone     string
+	CreatedAt time.Time
+}
+
+// CustomerFilterSrv - параметры выборки страницы покупателей
+type CustomerFilterSrv struct {
+	NameContains string
+	Email        string
+	Sort         string
+	Limit        int
+	Cursor       string
+}
+
+// CustomerPageSrv - страница покупателей и курсор следующей страницы
+type CustomerPageSrv struct {
+	Items      []CustomerSrv
+	NextCursor string
+}
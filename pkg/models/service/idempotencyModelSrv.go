@@ -0,0 +1,16 @@
+package service
+
+import "time"
+
+// IdempotencyKeySrv - ключ идемпотентности запроса и сохраненный ответ на него.
+// StatusCode равен 0, пока запрос с этим ключом обрабатывается
+type IdempotencyKeySrv struct {
+	Scope           string
+	Key             string
+	RequestHash     string
+	StatusCode      int
+	ResponseHeaders map[string]string
+	ResponseBody    []byte
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+}
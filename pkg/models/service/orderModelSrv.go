@@ -1,8 +1,50 @@
 package service
 
+import (
+	"tages-task-go/pkg/models/money"
+	"time"
+)
+
 type OrderSrv struct {
 	ID         int
-	ProductID  int
-	Quantity   int
-	TotalPrice float64
+	CustomerID int
+	Status     string
+	Items      []OrderItemSrv
+	TotalPrice money.Money
+	CreatedAt  time.Time
+}
+
+type OrderItemSrv struct {
+	ID        int
+	OrderID   int
+	ProductID int
+	Quantity  int
+	Price     money.Money
+}
+
+type OrderTransitionSrv struct {
+	ID         int
+	OrderID    int
+	FromStatus string
+	ToStatus   string
+	ChangedBy  string
+	ChangedAt  time.Time
+}
+
+// OrderFilterSrv - параметры выборки страницы заказов
+type OrderFilterSrv struct {
+	CustomerID  int
+	ProductID   int
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+	Limit       int
+	Cursor      string
+}
+
+// OrderPageSrv - страница заказов и курсор следующей страницы
+type OrderPageSrv struct {
+	Items      []*OrderSrv
+	NextCursor string
 }
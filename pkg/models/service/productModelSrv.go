@@ -1,7 +1,33 @@
 package service
 
+import "tages-task-go/pkg/models/money"
+
 type ProductSrv struct {
 	ID    int
 	Name  string
-	Price float64
+	Price money.Money
+	Stock int
+}
+
+// ProductFilterSrv - параметры выборки страницы продуктов
+type ProductFilterSrv struct {
+	MinPrice     *money.Decimal
+	MaxPrice     *money.Decimal
+	NameContains string
+	Sort         string
+	Limit        int
+	Cursor       string
+}
+
+// ProductPageSrv - страница продуктов и курсор следующей страницы
+type ProductPageSrv struct {
+	Items      []ProductSrv
+	NextCursor string
+}
+
+// ProductSearchResultSrv - продукт, найденный поиском, с релевантностью и подсвеченным названием
+type ProductSearchResultSrv struct {
+	Product   ProductSrv
+	Rank      float64
+	Highlight string
 }
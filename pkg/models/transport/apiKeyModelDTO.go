@@ -0,0 +1,24 @@
+package transport
+
+import "time"
+
+// APIKeyDTO - API-ключ межсервисного клиента. Поле key возвращается только один раз, при выпуске ключа
+type APIKeyDTO struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// APIKeyInputDTO - тело POST /admin/api-keys: ключ, префикс и служебные поля назначает сервер
+type APIKeyInputDTO struct {
+	Name      string     `json:"name" validate:"notblank,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=products:read products:write orders:read orders:write"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
@@ -0,0 +1,34 @@
+package transport
+
+import "time"
+
+type CustomerDTO struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CustomerInputDTO - тело POST, PUT и PATCH /customers. ID и время создания назначает сервер,
+// поэтому в теле они отклоняются как неизвестные поля
+type CustomerInputDTO struct {
+	Name  string `json:"name" validate:"notblank,max=100"`
+	Email string `json:"email" validate:"required,max=254,email"`
+	Phone string `json:"phone" validate:"omitempty,e164"`
+}
+
+// CustomerFilterDTO - параметры запроса GET /customers
+type CustomerFilterDTO struct {
+	NameContains string `json:"name" validate:"max=100"`
+	Email        string `json:"email" validate:"max=254"`
+	Sort         string `json:"sort" validate:"omitempty,oneof=id -id name -name created_at -created_at"`
+	Limit        int    `json:"limit" validate:"gte=0,lte=100"`
+	Cursor       string `json:"cursor" validate:"max=512"`
+}
+
+// CustomerPageDTO - страница покупателей в ответе GET /customers
+type CustomerPageDTO struct {
+	Items      []CustomerDTO `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,13 @@
+package transport
+
+// ComponentHealthDTO - состояние компонента сервиса в ответе /readyz
+type ComponentHealthDTO struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// HealthReportDTO - ответ проверок /healthz и /readyz
+type HealthReportDTO struct {
+	Status     string                        `json:"status"`
+	Components map[string]ComponentHealthDTO `json:"components,omitempty"`
+}
@@ -0,0 +1,6 @@
+package transport
+
+// LogLevelDTO - текущий уровень журнала сервиса
+type LogLevelDTO struct {
+	Level string `json:"level" validate:"required,oneof=trace debug info warn warning error fatal panic"`
+}
@@ -1,7 +1,66 @@
 package transport
 
+import (
+	"tages-task-go/pkg/models/money"
+	"time"
+)
+
 type OrderDTO struct {
-	ID        int `json:"id"`
-	ProductID int `json:"productId"`
-	Quantity  int `json:"quantity"`
+	ID         int            `json:"id"`
+	CustomerID int            `json:"customerId,omitempty"`
+	Status     string         `json:"status"`
+	Items      []OrderItemDTO `json:"items"`
+	TotalPrice money.Money    `json:"totalPrice"`
+	CreatedAt  time.Time      `json:"createdAt"`
+}
+
+type OrderItemDTO struct {
+	ProductID int         `json:"productId"`
+	Quantity  int         `json:"quantity"`
+	Price     money.Money `json:"price"`
+}
+
+// OrderCreateDTO - тело POST /orders. Содержит только поля, которые задает клиент: статус, цены,
+// сумма и время создания вычисляются сервером, и попытка передать их отклоняется как неизвестное поле
+type OrderCreateDTO struct {
+	CustomerID int                  `json:"customerId,omitempty" validate:"gte=0"`
+	Items      []OrderItemCreateDTO `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+// OrderItemCreateDTO - позиция в теле POST /orders, цена берется из каталога
+type OrderItemCreateDTO struct {
+	ProductID int `json:"productId" validate:"gt=0"`
+	Quantity  int `json:"quantity" validate:"gt=0,lte=10000"`
+}
+
+type OrderTransitionDTO struct {
+	ID        int       `json:"id"`
+	OrderID   int       `json:"orderId"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedBy string    `json:"changedBy"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// OrderTransitionCreateDTO - тело POST /orders/{id}/transitions: клиент задает только новый статус
+type OrderTransitionCreateDTO struct {
+	To string `json:"to" validate:"required,oneof=pending paid shipped delivered cancelled refunded"`
+}
+
+// OrderFilterDTO - параметры запроса GET /orders
+type OrderFilterDTO struct {
+	CustomerID  int        `json:"customer_id" validate:"gte=0"`
+	ProductID   int        `json:"product_id" validate:"gte=0"`
+	Status      string     `json:"status" validate:"omitempty,oneof=pending paid shipped delivered cancelled refunded"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	Sort        string     `json:"sort" validate:"omitempty,oneof=id -id created_at -created_at total_price -total_price"`
+	Limit       int        `json:"limit" validate:"gte=0,lte=100"`
+	Cursor      string     `json:"cursor" validate:"max=512"`
+}
+
+// OrderPageDTO - страница заказов в ответе GET /orders
+type OrderPageDTO struct {
+	Items      []OrderDTO `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
@@ -1,7 +1,65 @@
 package transport
 
+import "tages-task-go/pkg/models/money"
+
 type ProductDTO struct {
-	ID    int     `json:"id"`
-	Name  string  `json:"name" validate:"max=100"`
-	Price float64 `json:"price"`
+	ID    int         `json:"id"`
+	Name  string      `json:"name"`
+	Price money.Money `json:"price"`
+	Stock int         `json:"stock"`
+}
+
+// ProductCreateDTO - тело POST /products. ID назначает сервер, поэтому в теле он отклоняется
+type ProductCreateDTO struct {
+	Name  string      `json:"name" validate:"notblank,max=100"`
+	Price money.Money `json:"price" validate:"required,price"`
+	Stock int         `json:"stock" validate:"gte=0"`
+}
+
+// ProductUpdateDTO - тело PUT и PATCH /products/{id}. Остаток через них не меняется: заказы списывают его
+// параллельно, поэтому он изменяется только приращением через POST /products/{id}/stock
+type ProductUpdateDTO struct {
+	Name  string      `json:"name" validate:"notblank,max=100"`
+	Price money.Money `json:"price" validate:"required,price"`
+}
+
+// ProductStockAdjustmentDTO - тело POST /products/{id}/stock: на сколько увеличить (delta > 0)
+// или уменьшить (delta < 0) остаток
+type ProductStockAdjustmentDTO struct {
+	Delta int `json:"delta" validate:"ne=0,gte=-1000000,lte=1000000"`
+}
+
+// ProductFilterDTO - параметры запроса GET /products
+type ProductFilterDTO struct {
+	MinPrice     *money.Decimal `json:"min_price"`
+	MaxPrice     *money.Decimal `json:"max_price"`
+	NameContains string         `json:"name" validate:"max=100"`
+	Sort         string         `json:"sort" validate:"omitempty,oneof=id -id name -name price -price"`
+	Limit        int            `json:"limit" validate:"gte=0,lte=100"`
+	Cursor       string         `json:"cursor" validate:"max=512"`
+}
+
+// ProductPageDTO - страница продуктов в ответе GET /products
+type ProductPageDTO struct {
+	Items      []ProductDTO `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// ProductSearchDTO - параметры запроса GET /products/search
+type ProductSearchDTO struct {
+	Query string `json:"q" validate:"notblank,max=200"`
+	Limit int    `json:"limit" validate:"gte=0,lte=100"`
+}
+
+// ProductSearchResultDTO - продукт, найденный поиском. Highlight - название, экранированное для HTML,
+// в котором совпавшие слова обрамлены тегами <mark></mark>
+type ProductSearchResultDTO struct {
+	Product   ProductDTO `json:"product"`
+	Rank      float64    `json:"rank"`
+	Highlight string     `json:"highlight"`
+}
+
+// ProductSearchResultsDTO - ответ GET /products/search, результаты упорядочены по убыванию релевантности
+type ProductSearchResultsDTO struct {
+	Items []ProductSearchResultDTO `json:"items"`
 }
@@ -0,0 +1,18 @@
+package usecase
+
+import "time"
+
+// APIKeyUC - API-ключ межсервисного клиента. Key содержит ключ целиком
+// и заполняется только в ответе на выпуск ключа
+type APIKeyUC struct {
+	ID         int
+	Name       string
+	Prefix     string
+	Key        string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedBy  string
+	CreatedAt  time.Time
+}
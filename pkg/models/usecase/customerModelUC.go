@@ -0,0 +1,26 @@
+package usecase
+
+import "time"
+
+type CustomerUC struct {
+	ID        int
+	Name      string
+	Email     string
+	Phone     string
+	CreatedAt time.Time
+}
+
+// CustomerFilterUC - параметры выборки страницы покупателей
+type CustomerFilterUC struct {
+	NameContains string
+	Email        string
+	Sort         string
+	Limit        int
+	Cursor       string
+}
+
+// CustomerPageUC - страница покупателей и курсор следующей страницы
+type CustomerPageUC struct {
+	Items      []CustomerUC
+	NextCursor string
+}
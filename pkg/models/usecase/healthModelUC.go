@@ -0,0 +1,25 @@
+package usecase
+
+import "time"
+
+// HealthStatus - состояние сервиса или его компонента
+type HealthStatus string
+
+const (
+	HealthStatusUp   HealthStatus = "up"
+	HealthStatusDown HealthStatus = "down"
+)
+
+// ComponentHealthUC - результат проверки одного компонента, от которого зависит готовность сервиса.
+// Err - причина отказа: она пишется в журнал и не отдается клиенту, /readyz доступен без аутентификации
+type ComponentHealthUC struct {
+	Status   HealthStatus
+	Err      error
+	Duration time.Duration
+}
+
+// HealthReportUC - итог проверки готовности. Status равен up, только если все компоненты в состоянии up
+type HealthReportUC struct {
+	Status     HealthStatus
+	Components map[string]ComponentHealthUC
+}
@@ -0,0 +1,15 @@
+package usecase
+
+// IdempotentRequestUC - запрос, выполняемый не более одного раза для ключа Key в пределах Scope
+type IdempotentRequestUC struct {
+	Scope       string
+	Key         string
+	RequestHash string
+}
+
+// IdempotentResponseUC - сохраненный ответ на идемпотентный запрос
+type IdempotentResponseUC struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       []byte
+}
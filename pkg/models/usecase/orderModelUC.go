@@ -1,7 +1,60 @@
 package usecase
 
+import (
+	"tages-task-go/pkg/models/money"
+	"time"
+)
+
+// OrderStatus - статус заказа в его жизненном цикле
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
 type OrderUC struct {
-	ID        int
+	ID         int
+	CustomerID int
+	Status     OrderStatus
+	Items      []OrderItemUC
+	TotalPrice money.Money
+	CreatedAt  time.Time
+}
+
+type OrderItemUC struct {
 	ProductID int
 	Quantity  int
+	Price     money.Money
+}
+
+type OrderTransitionUC struct {
+	ID        int
+	OrderID   int
+	From      OrderStatus
+	To        OrderStatus
+	ChangedBy string
+	ChangedAt time.Time
+}
+
+// OrderFilterUC - параметры выборки страницы заказов
+type OrderFilterUC struct {
+	CustomerID  int
+	ProductID   int
+	Status      OrderStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+	Limit       int
+	Cursor      string
+}
+
+// OrderPageUC - страница заказов и курсор следующей страницы
+type OrderPageUC struct {
+	Items      []OrderUC
+	NextCursor string
 }
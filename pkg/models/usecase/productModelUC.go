@@ -1,7 +1,33 @@
 package usecase
 
+import "tages-task-go/pkg/models/money"
+
 type ProductUC struct {
 	ID    int
 	Name  string
-	Price float64
+	Price money.Money
+	Stock int
+}
+
+// ProductFilterUC - параметры выборки страницы продуктов
+type ProductFilterUC struct {
+	MinPrice     *money.Decimal
+	MaxPrice     *money.Decimal
+	NameContains string
+	Sort         string
+	Limit        int
+	Cursor       string
+}
+
+// ProductPageUC - страница продуктов и курсор следующей страницы
+type ProductPageUC struct {
+	Items      []ProductUC
+	NextCursor string
+}
+
+// ProductSearchResultUC - продукт, найденный поиском, с релевантностью и подсвеченным названием
+type ProductSearchResultUC struct {
+	Product   ProductUC
+	Rank      float64
+	Highlight string
 }
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock - управляемые часы для MemoryStore
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	// Один токен в секунду, до трех запросов подряд
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+	steps := []struct {
+		name           string
+		advance        time.Duration
+		wantAllowed    bool
+		wantRemaining  int
+		wantRetryAfter time.Duration
+		wantResetAfter time.Duration
+	}{
+		{name: "first request", wantAllowed: true, wantRemaining: 2, wantResetAfter: time.Second},
+		{name: "second request", wantAllowed: true, wantRemaining: 1, wantResetAfter: 2 * time.Second},
+		{name: "burst exhausted", wantAllowed: true, wantRemaining: 0, wantResetAfter: 3 * time.Second},
+		{name: "rejected", wantAllowed: false, wantRemaining: 0, wantRetryAfter: time.Second, wantResetAfter: 3 * time.Second},
+		{name: "half a token refilled", advance: 500 * time.Millisecond, wantAllowed: false,
+			wantRetryAfter: 500 * time.Millisecond, wantResetAfter: 2500 * time.Millisecond},
+		{name: "token refilled", advance: 500 * time.Millisecond, wantAllowed: true, wantRemaining: 0,
+			wantResetAfter: 3 * time.Second},
+		{name: "refill capped at burst", advance: time.Hour, wantAllowed: true, wantRemaining: 2,
+			wantResetAfter: time.Second},
+	}
+
+	store, clock := newTestStore()
+	for _, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+		got, err := store.Take(context.Background(), "client", limit)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		want := Result{
+			Allowed:    step.wantAllowed,
+			Limit:      3,
+			Remaining:  step.wantRemaining,
+			ResetAfter: step.wantResetAfter,
+			RetryAfter: step.wantRetryAfter,
+		}
+		if got != want {
+			t.Errorf("%s: Take = %+v, want %+v", step.name, got, want)
+		}
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Minute}
+	store, _ := newTestStore()
+	ctx := context.Background()
+
+	if result, _ := store.Take(ctx, "a", limit); !result.Allowed {
+		t.Fatal("first request of a rejected")
+	}
+	if result, _ := store.Take(ctx, "a", limit); result.Allowed {
+		t.Fatal("second request of a allowed")
+	}
+	if result, _ := store.Take(ctx, "b", limit); !result.Allowed {
+		t.Fatal("first request of b rejected")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 10}
+	store, clock := newTestStore()
+	ctx := context.Background()
+
+	store.Take(ctx, "idle", limit)
+	clock.now = clock.now.Add(sweepInterval)
+	store.Take(ctx, "active", limit)
+
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("full bucket of an idle client was not swept")
+	}
+	if _, ok := store.buckets["active"]; !ok {
+		t.Error("bucket of an active client was swept")
+	}
+}
+
+// failingStore имитирует недоступное хранилище корзин
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func TestLimiterAllow(t *testing.T) {
+	cfg := Config{
+		Enabled: true,
+		Default: Limit{Requests: 100, Period: time.Minute},
+		Groups:  map[string]Limit{"strict": {Requests: 1, Period: time.Minute}},
+	}
+	limiter, err := NewLimiter(cfg, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if result := limiter.Allow(ctx, "strict", "ip:1"); !result.Allowed || result.Limit != 1 {
+		t.Errorf("first strict request = %+v, want allowed with limit 1", result)
+	}
+	if result := limiter.Allow(ctx, "strict", "ip:1"); result.Allowed {
+		t.Errorf("second strict request = %+v, want rejected", result)
+	}
+	if result := limiter.Allow(ctx, "other", "ip:1"); !result.Allowed || result.Limit != 100 {
+		t.Errorf("request to a group without its own limit = %+v, want default limit", result)
+	}
+
+	failOpen, err := NewLimiter(cfg, failingStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := failOpen.Allow(ctx, "strict", "ip:1"); !result.Allowed {
+		t.Errorf("request with a failing store = %+v, want allowed", result)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "disabled skips checks", cfg: Config{}},
+		{name: "valid", cfg: Config{Enabled: true, Default: Limit{Requests: 10, Period: time.Second}}},
+		{name: "zero default", cfg: Config{Enabled: true}, wantErr: true},
+		{name: "negative burst", cfg: Config{Enabled: true, Default: Limit{Requests: 10, Period: time.Second, Burst: -1}}, wantErr: true},
+		{
+			name: "invalid group",
+			cfg: Config{Enabled: true, Default: Limit{Requests: 10, Period: time.Second},
+				Groups: map[string]Limit{"admin": {Requests: 1}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
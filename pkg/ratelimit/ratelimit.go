@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"tages-task-go/pkg/logging"
+	"time"
+)
+
+// Limit - параметры корзины токенов: Requests запросов за Period в среднем
+// и не больше Burst запросов подряд. Burst = 0 означает Burst = Requests
+type Limit struct {
+	Requests int           `yaml:"requests" env-default:"600"`
+	Period   time.Duration `yaml:"period" env-default:"1m"`
+	Burst    int           `yaml:"burst"`
+}
+
+// Rate возвращает скорость пополнения корзины в токенах в секунду
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Capacity возвращает емкость корзины
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+func (l Limit) validate() error {
+	if l.Requests <= 0 || l.Period <= 0 || l.Burst < 0 {
+		return fmt.Errorf("requests and period must be positive and burst must not be negative, got %+v", l)
+	}
+	return nil
+}
+
+// Result - итог попытки взять токен из корзины
+type Result struct {
+	Allowed bool
+	// Limit - емкость корзины
+	Limit int
+	// Remaining - сколько запросов еще можно сделать прямо сейчас
+	Remaining int
+	// ResetAfter - через сколько корзина наполнится полностью
+	ResetAfter time.Duration
+	// RetryAfter - через сколько появится следующий токен, если запрос отклонен
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние корзин. Реализация в памяти подходит для одного экземпляра сервиса,
+// для нескольких экземпляров ее можно заменить общим хранилищем
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Config - настройки ограничения частоты запросов. Default применяется к группам маршрутов,
+// для которых нет записи в Groups
+type Config struct {
+	Enabled bool             `yaml:"enabled" env-default:"true"`
+	Default Limit            `yaml:"default"`
+	Groups  map[string]Limit `yaml:"groups"`
+}
+
+// Validate проверяет, что все лимиты заданы корректно
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.Default.validate(); err != nil {
+		return fmt.Errorf("rate_limit.default: %w", err)
+	}
+	for group, limit := range c.Groups {
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("rate_limit.groups.%s: %w", group, err)
+		}
+	}
+	return nil
+}
+
+// Limiter применяет лимиты групп маршрутов к клиентам
+type Limiter struct {
+	store Store
+	cfg   Config
+}
+
+// NewLimiter создает ограничитель с лимитами из cfg и хранилищем корзин store
+func NewLimiter(cfg Config, store Store) (*Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Limiter{store: store, cfg: cfg}, nil
+}
+
+// Enabled сообщает, включено ли ограничение частоты запросов
+func (l *Limiter) Enabled() bool {
+	return l != nil && l.cfg.Enabled
+}
+
+// Allow берет токен из корзины клиента client в группе маршрутов group.
+// Если хранилище недоступно, запрос пропускается: сбой ограничителя не должен останавливать сервис
+func (l *Limiter) Allow(ctx context.Context, group, client string) Result {
+	limit, ok := l.cfg.Groups[group]
+	if !ok {
+		limit = l.cfg.Default
+	}
+	result, err := l.store.Take(ctx, group+"|"+client, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("Rate limit store failed, letting the request through: ", err)
+		return Result{Allowed: true, Limit: limit.Capacity(), Remaining: limit.Capacity()}
+	}
+	return result
+}
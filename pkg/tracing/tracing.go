@@ -0,0 +1,146 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+)
+
+// Экспортеры трассировки
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// instrumentationName - имя, под которым сервис создает спаны
+const instrumentationName = "tages-task-go"
+
+// Config - настройки трассировки OpenTelemetry
+type Config struct {
+	// Exporter - куда отправлять спаны: none, stdout, file или otlp
+	Exporter    string `yaml:"exporter" env-default:"none"`
+	ServiceName string `yaml:"service_name" env-default:"tages-task-go"`
+	// SampleRatio - доля трассируемых запросов без входящего traceparent, от 0 до 1
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	// FilePath - файл, в который экспортер file дописывает спаны в JSON
+	FilePath string `yaml:"file_path" env-default:"traces.json"`
+	// OTLPEndpoint - адрес коллектора OTLP/HTTP, например localhost:4318
+	OTLPEndpoint string `yaml:"otlp_endpoint" env-default:"localhost:4318"`
+	OTLPInsecure bool   `yaml:"otlp_insecure"`
+}
+
+// Validate проверяет настройки трассировки
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case ExporterNone, ExporterStdout:
+	case ExporterFile:
+		if c.FilePath == "" {
+			return fmt.Errorf("tracing: file_path is required for the file exporter")
+		}
+	case ExporterOTLP:
+		if c.OTLPEndpoint == "" {
+			return fmt.Errorf("tracing: otlp_endpoint is required for the otlp exporter")
+		}
+	default:
+		return fmt.Errorf("tracing: unknown exporter %q, expected none, stdout, file or otlp", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing: sample_ratio must be between 0 and 1, got %v", c.SampleRatio)
+	}
+	return nil
+}
+
+// Shutdown отправляет накопленные спаны и освобождает ресурсы экспортера
+type Shutdown func(ctx context.Context) error
+
+// Init настраивает глобальные провайдер трассировки и W3C-пропагатор (traceparent, baggage).
+// Пропагатор устанавливается и при выключенной трассировке, чтобы контекст входящих запросов не терялся
+func Init(ctx context.Context, cfg Config) (Shutdown, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closeOutput, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			if closeErr := closeOutput(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// newExporter создает экспортер по настройкам. Для экспортера file также возвращается функция закрытия файла
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, func() error, error) {
+	switch cfg.Exporter {
+	case ExporterNone:
+		return nil, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, err
+	case ExporterFile:
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exporter, file.Close, nil
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		return exporter, nil, err
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q, expected none, stdout, file or otlp", cfg.Exporter)
+	}
+}
+
+// Start начинает дочерний спан с именем name
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// RecordError отмечает спан как завершившийся ошибкой err
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}